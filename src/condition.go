@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// operators of exclude and include conditions, longest first, as they are matched in this order
+// '=' compares the prefix, to be able to match actions like "exec_XXX: YYYY" which use a
+// special, dynamic, syntax
+// see https://github.com/moby/moby/blob/bf053be997f87af233919a76e6ecbd7d17390e62/api/types/events/events.go#L74-L81
+const (
+	operatorNotRegex = "!~="
+	operatorNotGlob  = "!*="
+	operatorExact    = "=="
+	operatorNotExact = "!="
+	operatorRegex    = "~="
+	operatorGlob     = "*="
+	operatorPrefix   = "="
+)
+
+var operators = []string{operatorNotRegex, operatorNotGlob, operatorExact, operatorNotExact, operatorRegex, operatorGlob, operatorPrefix}
+
+// condition compares a single key of an event with a value, e.g. "Action==start"
+type condition struct {
+	key      string
+	operator string
+	value    string
+	// compiled glob or regular expression
+	pattern *regexp.Regexp
+}
+
+func parseCondition(text string) (condition, error) {
+	// The key ends at the first '=', the operator might start before it
+
+	pos := strings.Index(text, "=")
+	if pos == -1 {
+		return condition{}, fmt.Errorf("\"%s\" should be of the form key=value", text)
+	}
+
+	var c condition
+	for _, operator := range operators {
+		// position of the operator, if it contains the first '='
+		start := pos - strings.Index(operator, "=")
+		if start >= 0 && strings.HasPrefix(text[start:], operator) {
+			c = condition{
+				//trim whitespaces
+				key:      strings.TrimSpace(text[:start]),
+				operator: operator,
+				value:    text[start+len(operator):],
+			}
+			break
+		}
+	}
+	if len(c.key) == 0 {
+		return condition{}, fmt.Errorf("\"%s\" has no key", text)
+	}
+
+	var err error
+	switch c.operator {
+	case operatorRegex, operatorNotRegex:
+		c.pattern, err = regexp.Compile(c.value)
+	case operatorGlob, operatorNotGlob:
+		c.pattern, err = compileGlob(c.value)
+	}
+	if err != nil {
+		return condition{}, fmt.Errorf("\"%s\" has an invalid pattern: %w", text, err)
+	}
+	return c, nil
+}
+
+// converts a glob pattern to a regular expression
+// '*' matches any number of characters (including '/'), '?' a single character
+func compileGlob(glob string) (*regexp.Regexp, error) {
+	var builder strings.Builder
+	builder.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			builder.WriteString(".*")
+		case '?':
+			builder.WriteString(".")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	builder.WriteString("$")
+	return regexp.Compile(builder.String())
+}
+
+// negated conditions match if the value does not
+func (c condition) negated() bool {
+	return strings.HasPrefix(c.operator, "!")
+}
+
+// checks the condition against the flattened event
+// A missing key counts as an empty value for negated conditions, all others don't match
+func (c condition) matchesEvent(eventMap map[string]string) bool {
+	eventValue, keyExist := eventMap[c.key]
+	if !keyExist && !c.negated() {
+		return false
+	}
+	return c.matches(eventValue)
+}
+
+func (c condition) matches(eventValue string) bool {
+	var matched bool
+	switch c.operator {
+	case operatorPrefix:
+		matched = strings.HasPrefix(eventValue, c.value)
+	case operatorExact, operatorNotExact:
+		matched = eventValue == c.value
+	default:
+		matched = c.pattern.MatchString(eventValue)
+	}
+	return matched != c.negated()
+}
+
+func (c condition) String() string {
+	return c.key + c.operator + c.value
+}
+
+// rule is a group of conditions which all have to match (AND), e.g. "Type=container&Action=exec_start"
+// A list of rules matches if any of them matches (OR)
+type rule []condition
+
+// '&' always separates conditions, so it can't be part of a value
+// Regular expressions can use "\x26" instead
+func parseRule(text string) (rule, error) {
+	var r rule
+	for _, part := range strings.Split(text, "&") {
+		if len(r) > 0 && !strings.Contains(part, "=") {
+			return nil, fmt.Errorf("\"%s\": '&' separates conditions and can't be used in values, \"%s\" is no condition", text, part)
+		}
+		c, err := parseCondition(part)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, c)
+	}
+	return r, nil
+}
+
+func (r rule) matches(eventMap map[string]string) bool {
+	for _, c := range r {
+		if !c.matchesEvent(eventMap) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r rule) String() string {
+	var conditions []string
+	for _, c := range r {
+		conditions = append(conditions, c.String())
+	}
+	return strings.Join(conditions, "&")
+}
+
+// returns the first matching rule, rules are checked in the order they were supplied
+func matchRules(rules []rule, eventMap map[string]string) (rule, bool) {
+	for _, r := range rules {
+		if r.matches(eventMap) {
+			return r, true
+		}
+	}
+	return nil, false
+}
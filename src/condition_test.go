@@ -0,0 +1,261 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		text     string
+		key      string
+		operator string
+		value    string
+		wantErr  bool
+	}{
+		{text: "Action==start", key: "Action", operator: operatorExact, value: "start"},
+		{text: "Action=exec_", key: "Action", operator: operatorPrefix, value: "exec_"},
+		{text: "Actor.Attributes.name*=*-tmp", key: "Actor.Attributes.name", operator: operatorGlob, value: "*-tmp"},
+		{text: "Actor.Attributes.image~=^ghcr\\.io/", key: "Actor.Attributes.image", operator: operatorRegex, value: "^ghcr\\.io/"},
+		{text: "Actor.Attributes.com.docker.compose.project!=ci", key: "Actor.Attributes.com.docker.compose.project", operator: operatorNotExact, value: "ci"},
+		{text: "Actor.Attributes.image!*=ghcr.io/*", key: "Actor.Attributes.image", operator: operatorNotGlob, value: "ghcr.io/*"},
+		{text: "Actor.Attributes.image!~=^ghcr", key: "Actor.Attributes.image", operator: operatorNotRegex, value: "^ghcr"},
+		// whitespace around the key is trimmed, the value is kept as is
+		{text: " Action ==start ", key: "Action", operator: operatorExact, value: "start "},
+		// the value may contain '=' and operator characters
+		{text: "Actor.Attributes.env==A=B", key: "Actor.Attributes.env", operator: operatorExact, value: "A=B"},
+		{text: "Action=!~=", key: "Action", operator: operatorPrefix, value: "!~="},
+		// keys containing operator characters, as long as they don't end with them
+		{text: "Actor.Attributes.a*b==x", key: "Actor.Attributes.a*b", operator: operatorExact, value: "x"},
+		{text: "Actor.Attributes.a!b=x", key: "Actor.Attributes.a!b", operator: operatorPrefix, value: "x"},
+		{text: "Actor.Attributes.a~b~=x", key: "Actor.Attributes.a~b", operator: operatorRegex, value: "x"},
+		{text: "Actor.Attributes.a!b!=x", key: "Actor.Attributes.a!b", operator: operatorNotExact, value: "x"},
+		// an empty value is allowed
+		{text: "Action==", key: "Action", operator: operatorExact, value: ""},
+		{text: "Action", wantErr: true},
+		{text: "=start", wantErr: true},
+		{text: "==start", wantErr: true},
+		{text: "!~=start", wantErr: true},
+		{text: "Action~=(start", wantErr: true},
+		{text: "Action!~=[", wantErr: true},
+	}
+
+	for _, test := range tests {
+		c, err := parseCondition(test.text)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parseCondition(%q): expected an error, got %+v", test.text, c)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseCondition(%q): unexpected error: %v", test.text, err)
+			continue
+		}
+		if c.key != test.key || c.operator != test.operator || c.value != test.value {
+			t.Errorf("parseCondition(%q) = %q %q %q, want %q %q %q", test.text, c.key, c.operator, c.value, test.key, test.operator, test.value)
+		}
+	}
+}
+
+func TestConditionMatches(t *testing.T) {
+	tests := []struct {
+		condition string
+		value     string
+		want      bool
+	}{
+		{"Action==start", "start", true},
+		{"Action==start", "restart", false},
+		{"Action==start", "start2", false},
+		// '=' keeps its old meaning of comparing the prefix
+		{"Action=exec_", "exec_start: sh -c true", true},
+		{"Action=exec_", "exec_die", true},
+		{"Action=exec_", "start", false},
+		{"Action=start", "start", true},
+		{"Action=", "anything", true},
+		{"name*=*-tmp", "build-tmp", true},
+		{"name*=*-tmp", "build-tmp-1", false},
+		{"name*=web-?", "web-1", true},
+		{"name*=web-?", "web-10", false},
+		{"image*=ghcr.io/ourorg/*", "ghcr.io/ourorg/app/api:1", true},
+		{"image*=ghcr.io/ourorg/*", "ghcrXio/ourorg/app", false},
+		{"image~=^ghcr\\.io/ourorg/", "ghcr.io/ourorg/app", true},
+		{"image~=ourorg", "ghcr.io/ourorg/app", true},
+		{"image~=^ourorg", "ghcr.io/ourorg/app", false},
+		{"project!=ci", "shop", true},
+		{"project!=ci", "ci", false},
+		{"image!*=ghcr.io/ourorg/*", "docker.io/library/nginx", true},
+		{"image!*=ghcr.io/ourorg/*", "ghcr.io/ourorg/app", false},
+		{"image!~=^ghcr", "docker.io/library/nginx", true},
+		{"image!~=^ghcr", "ghcr.io/ourorg/app", false},
+	}
+
+	for _, test := range tests {
+		c, err := parseCondition(test.condition)
+		if err != nil {
+			t.Fatalf("parseCondition(%q): %v", test.condition, err)
+		}
+		if got := c.matches(test.value); got != test.want {
+			t.Errorf("%q matches %q = %v, want %v", test.condition, test.value, got, test.want)
+		}
+	}
+}
+
+func TestConditionMatchesMissingKey(t *testing.T) {
+	// only negated conditions match events without the key, as if its value was empty
+	eventMap := map[string]string{"Action": "start"}
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"Actor.Attributes.name==web", false},
+		{"Actor.Attributes.name=", false},
+		{"Actor.Attributes.name*=*", false},
+		{"Actor.Attributes.name~=.*", false},
+		{"Actor.Attributes.name!=web", true},
+		{"Actor.Attributes.name!*=web*", true},
+		{"Actor.Attributes.name!~=^web", true},
+		{"Action==start", true},
+	}
+
+	for _, test := range tests {
+		c, err := parseCondition(test.condition)
+		if err != nil {
+			t.Fatalf("parseCondition(%q): %v", test.condition, err)
+		}
+		if got := c.matchesEvent(eventMap); got != test.want {
+			t.Errorf("%q matches %v = %v, want %v", test.condition, eventMap, got, test.want)
+		}
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		size    int
+		wantErr bool
+	}{
+		{text: "Action=exec_", want: "Action=exec_", size: 1},
+		{text: "Type=container&Action=exec_start", want: "Type=container&Action=exec_start", size: 2},
+		{text: "Type==container&Action!~=^exec_&Actor.Attributes.name*=web-*", want: "Type==container&Action!~=^exec_&Actor.Attributes.name*=web-*", size: 3},
+		{text: "Actor.Attributes.name~=a\\x26b", want: "Actor.Attributes.name~=a\\x26b", size: 1},
+		// '&' can't be used in values
+		{text: "Action~=a&b", wantErr: true},
+		{text: "Type=container&", wantErr: true},
+		{text: "&Type=container", wantErr: true},
+		{text: "Type=container&Action", wantErr: true},
+		{text: "Type=container&Action~=(", wantErr: true},
+	}
+
+	for _, test := range tests {
+		r, err := parseRule(test.text)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parseRule(%q): expected an error, got %q", test.text, r)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRule(%q): unexpected error: %v", test.text, err)
+			continue
+		}
+		if r.String() != test.want || len(r) != test.size {
+			t.Errorf("parseRule(%q) = %q (%d conditions), want %q (%d conditions)", test.text, r, len(r), test.want, test.size)
+		}
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	eventMap := map[string]string{
+		"Type":                  "container",
+		"Action":                "exec_start: sh",
+		"Actor.Attributes.name": "web-1",
+	}
+
+	tests := []struct {
+		rule string
+		want bool
+	}{
+		{"Type=container&Action=exec_start", true},
+		{"Type=container&Action=exec_die", false},
+		{"Type=image&Action=exec_start", false},
+		{"Type==container&Actor.Attributes.name*=web-*&Action~=sh$", true},
+		// the first key is missing, a later one matches: the rule must not match
+		{"Actor.Attributes.image=nginx&Type=container", false},
+		{"Type=container&Actor.Attributes.image=nginx", false},
+		// negated conditions match missing keys
+		{"Actor.Attributes.image!=nginx&Type=container", true},
+	}
+
+	for _, test := range tests {
+		r, err := parseRule(test.rule)
+		if err != nil {
+			t.Fatalf("parseRule(%q): %v", test.rule, err)
+		}
+		if got := r.matches(eventMap); got != test.want {
+			t.Errorf("%q matches %v = %v, want %v", test.rule, eventMap, got, test.want)
+		}
+	}
+}
+
+func TestMatchRules(t *testing.T) {
+	tests := []struct {
+		rules   []string
+		event   map[string]string
+		want    string
+		matched bool
+	}{
+		{
+			rules:   nil,
+			event:   map[string]string{"Type": "container"},
+			matched: false,
+		},
+		{
+			// the original bug: the first key doesn't exist, the second one matches
+			rules:   []string{"Actor.Attributes.image=nginx", "Action=exec_start"},
+			event:   map[string]string{"Type": "container", "Action": "exec_start: sh"},
+			want:    "Action=exec_start",
+			matched: true,
+		},
+		{
+			rules:   []string{"Action=exec_start", "Actor.Attributes.image=nginx"},
+			event:   map[string]string{"Type": "container", "Action": "exec_start: sh"},
+			want:    "Action=exec_start",
+			matched: true,
+		},
+		{
+			rules:   []string{"Type=container&Action=exec_start", "Type=image"},
+			event:   map[string]string{"Type": "image", "Action": "pull"},
+			want:    "Type=image",
+			matched: true,
+		},
+		{
+			rules:   []string{"Type=container&Action=exec_start", "Type=image"},
+			event:   map[string]string{"Type": "container", "Action": "start"},
+			matched: false,
+		},
+		{
+			// the first matching rule is returned
+			rules:   []string{"Type=container", "Action=start"},
+			event:   map[string]string{"Type": "container", "Action": "start"},
+			want:    "Type=container",
+			matched: true,
+		},
+	}
+
+	for _, test := range tests {
+		var rules []rule
+		for _, text := range test.rules {
+			r, err := parseRule(text)
+			if err != nil {
+				t.Fatalf("parseRule(%q): %v", text, err)
+			}
+			rules = append(rules, r)
+		}
+		r, matched := matchRules(rules, test.event)
+		if matched != test.matched || r.String() != test.want {
+			t.Errorf("matchRules(%q, %v) = %q, %v, want %q, %v", test.rules, test.event, r, matched, test.want, test.matched)
+		}
+	}
+}
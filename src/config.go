@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the structure of the configuration file
+// Environment variables and flags take precedence over the settings in the file
+type fileConfig struct {
+	Delay           *duration                  `json:"delay"`
+	LogLevel        string                     `json:"log_level"`
+	ServerTag       string                     `json:"server_tag"`
+	StateFile       string                     `json:"state_file"`
+	ListenAddress   string                     `json:"listen_address"`
+	Retries         *int                       `json:"retries"`
+	RetryDelay      *duration                  `json:"retry_delay"`
+	OutboxDir       string                     `json:"outbox_dir"`
+	OutboxInterval  *duration                  `json:"outbox_interval"`
+	QueueSize       int                        `json:"queue_size"`
+	QueueOverflow   string                     `json:"queue_overflow"`
+	TitleTemplate   string                     `json:"title_template"`
+	MessageTemplate string                     `json:"message_template"`
+	Filter          []string                   `json:"filter"`
+	Exclude         []string                   `json:"exclude"`
+	Include         []string                   `json:"include"`
+	ExcludeExpr     string                     `json:"exclude_expr"`
+	IncludeExpr     string                     `json:"include_expr"`
+	LabelEnable     bool                       `json:"label_enable"`
+	Notifiers       map[string]json.RawMessage `json:"notifiers"`
+	Routes          []route                    `json:"routes"`
+	DefaultRoute    []string                   `json:"default_route"`
+	QuietHours      []quietWindow              `json:"quiet_hours"`
+}
+
+// the parsed configuration file, empty if no file is used
+var glb_config fileConfig
+
+func configPath() string {
+	// The path of the configuration file is needed before the arguments are parsed,
+	// so it's looked up manually
+
+	for i, arg := range os.Args[1:] {
+		if arg == "--config" && i+2 < len(os.Args) {
+			return os.Args[i+2]
+		}
+		if strings.HasPrefix(arg, "--config=") {
+			return strings.TrimPrefix(arg, "--config=")
+		}
+	}
+	return os.Getenv("CONFIG")
+}
+
+func loadConfig(path string) (fileConfig, error) {
+	// Reads a YAML or TOML configuration file, depending on the file extension
+
+	var config fileConfig
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+
+	// Decode the file into a generic map first, which is converted to the config struct via JSON
+	// This way, both formats share the same field names and the notifier settings
+	// can be decoded later on by the respective notifier
+	m := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &m)
+	case ".toml":
+		err = toml.Unmarshal(content, &m)
+	default:
+		return config, fmt.Errorf("unsupported configuration file format \"%s\", use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	if err != nil {
+		return config, err
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return config, err
+	}
+	err = decodeStrict(b, &config)
+	return config, err
+}
+
+func applyConfig(config fileConfig) {
+	// Uses the settings of the configuration file as defaults for the run-time arguments
+	// Must be called before the arguments are parsed
+
+	if config.Delay != nil {
+		glb_arguments.Delay = time.Duration(*config.Delay)
+	}
+	if len(config.LogLevel) > 0 {
+		glb_arguments.LogLevel = config.LogLevel
+	}
+	if len(config.ServerTag) > 0 {
+		glb_arguments.ServerTag = config.ServerTag
+	}
+	if len(config.ListenAddress) > 0 {
+		glb_arguments.ListenAddress = config.ListenAddress
+	}
+	if len(config.StateFile) > 0 {
+		glb_arguments.StateFile = config.StateFile
+	}
+	if config.Retries != nil {
+		glb_arguments.Retries = *config.Retries
+	}
+	if config.RetryDelay != nil {
+		glb_arguments.RetryDelay = time.Duration(*config.RetryDelay)
+	}
+	if len(config.OutboxDir) > 0 {
+		glb_arguments.OutboxDir = config.OutboxDir
+	}
+	if config.OutboxInterval != nil {
+		glb_arguments.OutboxInterval = time.Duration(*config.OutboxInterval)
+	}
+	if config.QueueSize > 0 {
+		glb_arguments.QueueSize = config.QueueSize
+	}
+	if len(config.QueueOverflow) > 0 {
+		glb_arguments.QueueOverflow = config.QueueOverflow
+	}
+	if len(config.TitleTemplate) > 0 {
+		glb_arguments.TitleTemplate = config.TitleTemplate
+	}
+	if len(config.MessageTemplate) > 0 {
+		glb_arguments.MessageTemplate = config.MessageTemplate
+	}
+	if len(config.ExcludeExpr) > 0 {
+		glb_arguments.ExcludeExpr = config.ExcludeExpr
+	}
+	if len(config.IncludeExpr) > 0 {
+		glb_arguments.IncludeExpr = config.IncludeExpr
+	}
+	if config.LabelEnable {
+		glb_arguments.LabelEnable = true
+	}
+}
+
+func notifiersFromConfig(instances map[string]json.RawMessage) []Notifier {
+	// Creates all notifier instances declared in the configuration file
+
+	var configured []Notifier
+
+	// sort the names, so the notifiers are always set up in the same order
+	names := make([]string, 0, len(instances))
+	for name := range instances {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		notifier, err := notifierFromConfig(name, instances[name])
+		if err != nil {
+			logger.Fatal().Err(err).Str("reporter", name).Msg("Invalid notifier in configuration file")
+		}
+		configured = append(configured, notifier)
+	}
+	return configured
+}
+
+func notifierFromConfig(name string, raw json.RawMessage) (Notifier, error) {
+	// The type of the notifier is stored alongside its settings
+	settings := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return nil, err
+	}
+	kindName, _ := settings["type"].(string)
+	delete(settings, "type")
+
+	for _, kind := range registry {
+		if !strings.EqualFold(kind.name, kindName) {
+			continue
+		}
+		notifier := kind.new(name)
+		if err := loadSecretSettings(settings, notifier); err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(settings)
+		if err != nil {
+			return nil, err
+		}
+		if err := decodeStrict(b, notifier); err != nil {
+			return nil, err
+		}
+		return notifier, nil
+	}
+	return nil, fmt.Errorf("unknown notifier type \"%s\"", kindName)
+}
+
+func overrideNotifier(dst Notifier, src Notifier) error {
+	// Overrides the settings of dst with all non-empty settings of src
+	// Notifier fields are tagged with 'omitempty', so only set values are copied
+	b, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dst)
+}
+
+// duration can be set as string like "500ms" in the configuration file
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// decodes JSON and fails on unknown fields, which are most likely typos in the configuration file
+func decodeStrict(b []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestConfigZeroValues(t *testing.T) {
+	// zero values in the configuration file must not be replaced by the defaults
+	path := t.TempDir() + "/config.yaml"
+	if err := os.WriteFile(path, []byte("retries: 0\ndelay: 0s\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG", path)
+
+	arguments := os.Args
+	os.Args = os.Args[:1]
+	defer func() {
+		os.Args = arguments
+		glb_arguments = args{}
+		glb_config = fileConfig{}
+	}()
+
+	glb_arguments = args{}
+	parseArgs()
+	if glb_arguments.Retries != 0 {
+		t.Errorf("Retries = %d, want 0", glb_arguments.Retries)
+	}
+	if glb_arguments.Delay != 0 {
+		t.Errorf("Delay = %s, want 0s", glb_arguments.Delay)
+	}
+
+	// without configuration file, the defaults are used
+	t.Setenv("CONFIG", "")
+	glb_arguments = args{}
+	glb_config = fileConfig{}
+	parseArgs()
+	if glb_arguments.Retries != 3 {
+		t.Errorf("Retries = %d, want 3", glb_arguments.Retries)
+	}
+	if glb_arguments.Delay != 500*time.Millisecond {
+		t.Errorf("Delay = %s, want 500ms", glb_arguments.Delay)
+	}
+}
+
+func TestConfigDurations(t *testing.T) {
+	path := t.TempDir() + "/config.yaml"
+	content := "delay: 250ms\nretry_delay: 0s\noutbox_interval: 30s\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG", path)
+
+	arguments := os.Args
+	os.Args = os.Args[:1]
+	defer func() {
+		os.Args = arguments
+		glb_arguments = args{}
+		glb_config = fileConfig{}
+	}()
+
+	glb_arguments = args{}
+	parseArgs()
+	if glb_arguments.Delay != 250*time.Millisecond {
+		t.Errorf("Delay = %s, want 250ms", glb_arguments.Delay)
+	}
+	if glb_arguments.RetryDelay != 0 {
+		t.Errorf("RetryDelay = %s, want 0s", glb_arguments.RetryDelay)
+	}
+	if glb_arguments.OutboxInterval != 30*time.Second {
+		t.Errorf("OutboxInterval = %s, want 30s", glb_arguments.OutboxInterval)
+	}
+
+	if err := os.WriteFile(path, []byte("delay: 5\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(path); err == nil {
+		t.Error("expected an error for a delay without unit")
+	}
+}
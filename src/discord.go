@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type discordArgs struct {
+	Discord     bool   `arg:"env:DISCORD" default:"false" help:"Enable/Disable Discord Notification (True/False)"`
+	DiscordURL  secret `arg:"env:DISCORD_URL" help:"URL of your Discord webhook"`
+	DiscordUser string `arg:"env:DISCORD_USER" help:"Discord user name to post as, overrides the webhook's default user name"`
+}
+
+var discordKind = notifierKind{
+	name: "Discord",
+	fromArgs: func() (Notifier, bool) {
+		notifier := &discordNotifier{
+			name: "Discord",
+			URL:  glb_arguments.DiscordURL,
+			User: glb_arguments.DiscordUser,
+		}
+		return notifier, glb_arguments.Discord
+	},
+	new: func(name string) Notifier {
+		return &discordNotifier{name: name}
+	},
+}
+
+type discordNotifier struct {
+	name string
+	notifierOptions
+	URL  secret `json:"url,omitempty"`
+	User string `json:"user,omitempty"`
+}
+
+// limits of Discord embeds
+// see https://discord.com/developers/docs/resources/message#embed-object-embed-limits
+const (
+	discordTitleLength       = 256
+	discordDescriptionLength = 4096
+	discordFieldNameLength   = 256
+	discordFieldValueLength  = 1024
+	discordFieldCount        = 25
+)
+
+// DiscordMessage is a message for a Discord webhook
+// see https://discord.com/developers/docs/resources/webhook#execute-webhook
+type DiscordMessage struct {
+	Username string         `json:"username,omitempty"`
+	Embeds   []DiscordEmbed `json:"embeds"`
+}
+
+type DiscordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description,omitempty"`
+	Timestamp   string              `json:"timestamp,omitempty"`
+	Color       int                 `json:"color,omitempty"`
+	Fields      []DiscordEmbedField `json:"fields,omitempty"`
+}
+
+type DiscordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// body of Discord's 429 responses
+type discordRateLimit struct {
+	// seconds to wait before trying again
+	RetryAfter float64 `json:"retry_after"`
+}
+
+func (d *discordNotifier) Name() string {
+	return d.name
+}
+
+func (d *discordNotifier) Validate() error {
+	if len(d.URL) == 0 {
+		return errors.New("Discord URL required")
+	}
+	if _, err := url.Parse(string(d.URL)); err != nil {
+		return errors.New("Discord URL invalid")
+	}
+	return nil
+}
+
+func (d *discordNotifier) Describe() []setting {
+	return []setting{
+		{key: "DiscordURL", value: d.URL.String()},
+		{key: "DiscordUser", value: d.User},
+	}
+}
+
+// Send a message to a Discord channel
+func (d *discordNotifier) Send(ctx context.Context, n Notification) error {
+
+	embed := DiscordEmbed{
+		Title:     truncate(n.Title, discordTitleLength),
+		Timestamp: n.Timestamp.Format(time.RFC3339),
+	}
+
+	if n.Event != nil {
+		embed.Timestamp = time.Unix(n.Event.Time, 0).Format(time.RFC3339)
+		embed.Color = discordColor(eventSeverity(*n.Event))
+	}
+
+	// Every "Key: value" line of the message becomes a field, everything else the description
+	var description []string
+	for _, line := range strings.Split(n.Message, "\n") {
+		key, value, found := strings.Cut(line, ": ")
+		if !found || len(key) == 0 || len(embed.Fields) == discordFieldCount {
+			description = append(description, line)
+			continue
+		}
+		embed.Fields = append(embed.Fields, DiscordEmbedField{
+			Name:   truncate(key, discordFieldNameLength),
+			Value:  truncate(value, discordFieldValueLength),
+			Inline: true,
+		})
+	}
+	embed.Description = truncate(strings.TrimSpace(strings.Join(description, "\n")), discordDescriptionLength)
+
+	message := DiscordMessage{
+		Username: d.User,
+		Embeds:   []DiscordEmbed{embed},
+	}
+
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	// Discord answers with 204 No Content by default, 'wait' makes it return 200 with the created message
+	address, err := url.Parse(string(d.URL))
+	if err != nil {
+		return err
+	}
+	query := address.Query()
+	query.Set("wait", "true")
+	address.RawQuery = query.Encode()
+
+	_, err = sendhttpRequest(ctx, d, httpRequest{
+		method:     http.MethodPost,
+		address:    address.String(),
+		body:       messageJSON,
+		retryAfter: discordRetryAfter,
+	})
+	return err
+}
+
+// Discord tells the exact time to wait in the body of rate limited responses
+func discordRetryAfter(body []byte) time.Duration {
+	var rateLimit discordRateLimit
+	if json.Unmarshal(body, &rateLimit) != nil {
+		return 0
+	}
+	return time.Duration(rateLimit.RetryAfter * float64(time.Second))
+}
+
+func discordColor(s severity) int {
+	switch s {
+	case severityGood:
+		return 0x2eb886
+	case severityWarning:
+		return 0xdaa038
+	case severityCritical:
+		return 0xa30200
+	}
+	return 0x808080
+}
+
+// shortens a text to the given number of characters
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length-1]) + "…"
+}
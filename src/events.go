@@ -10,61 +10,39 @@ import (
 	"golang.org/x/text/language"
 )
 
-func processEvent(event events.Message) {
+func processEvent(event events.Message, replayed bool) {
 	// the Docker Events endpoint will return a struct events.Message
 	// https://pkg.go.dev/github.com/docker/docker/api/types/events#Message
 
 	var msg_builder, title_builder strings.Builder
-	var ActorID, ActorImage, ActorName, TitleID, ActorImageVersion string
-
-	// Adding a small configurable delay here
-	// Sometimes events are pushed through the event channel really quickly, but they arrive on the notification clients in
-	// wrong order (probably due to message delivery time), e.g. Pushover is susceptible for this.
-	// Finishing this function not before a certain time before draining the next event from the event channel in main() solves the issue
-	timer := time.NewTimer(glb_arguments.Delay)
+	var ActorID, ActorName, TitleID string
 
 	ActorID = getActorID(event)
-	ActorImage = getActorImage(event)
 	ActorName = getActorName(event)
-	ActorImageVersion = getActorImageVersion(event)
 
-	// Check possible image and container name
 	// The order of the checks is important, because we want name rather than ActorID
 	// as identifier in the title
+	// Not using ActorImage as possible title, because it's too long
 	if len(ActorID) > 0 {
-		msg_builder.WriteString("ID: " + ActorID + "\n")
 		TitleID = ActorID
 	}
-	if len(ActorImage) > 0 {
-		msg_builder.WriteString("Image: " + ActorImage + "\n")
-		// Not using ActorImage as possible title, because it's too long
-	}
-	if len(ActorImageVersion) > 0 {
-		msg_builder.WriteString("Image version: " + ActorImageVersion + "\n")
-	}
-
 	if len(ActorName) > 0 {
-		msg_builder.WriteString("Name: " + ActorName + "\n")
 		TitleID = ActorName
 	}
 
 	// Build title
+	// mark events which happend while the monitor was not running
+	if replayed {
+		title_builder.WriteString("[Replayed] ")
+	}
 	title_builder.WriteString(cases.Title(language.English, cases.Compact).String(string(event.Type)))
 	if len(TitleID) > 0 {
 		title_builder.WriteString(" " + TitleID)
 	}
 	title_builder.WriteString(": " + string(event.Action))
 
-	// Get event timestamp
-	timestamp := time.Unix(event.Time, 0)
-	msg_builder.WriteString("Time: " + timestamp.Format(time.RFC1123Z) + "\n")
-
-	// Append possible docker compose context
-	if len(event.Actor.Attributes["com.docker.compose.project.working_dir"]) > 0 {
-		msg_builder.WriteString("Docker compose context: " + event.Actor.Attributes["com.docker.compose.project.working_dir"] + "\n")
-	}
-	if len(event.Actor.Attributes["com.docker.compose.service"]) > 0 {
-		msg_builder.WriteString("Docker compose service: " + event.Actor.Attributes["com.docker.compose.service"] + "\n")
+	for _, field := range eventFields(event) {
+		msg_builder.WriteString(field.name + ": " + field.value + "\n")
 	}
 
 	// Build message and title
@@ -76,22 +54,55 @@ func processEvent(event events.Message) {
 		Str("eventType", string(event.Type)).
 		Str("ActorID", ActorID).
 		Str("eventAction", string(event.Action)).
-		Str("ActorImage", ActorImage).
-		Str("ActorImageVersion", ActorImageVersion).
+		Str("ActorImage", getActorImage(event)).
+		Str("ActorImageVersion", getActorImageVersion(event)).
 		Str("ActorName", ActorName).
 		Str("DockerComposeContext", event.Actor.Attributes["com.docker.compose.project.working_dir"]).
 		Str("DockerComposeService", event.Actor.Attributes["com.docker.compose.service"]).
+		Bool("replayed", replayed).
 		Msg(title)
 
 	// send notifications to various reporters
-	// function will finish when all reporters finished
-	sendNotifications(timestamp, message, title)
+	// function returns immediately, notifications are delivered in the background
+	sendNotifications(Notification{
+		Timestamp: time.Unix(event.Time, 0),
+		Title:     title,
+		Message:   message,
+		Event:     &event,
+		Replayed:  replayed,
+	})
+}
+
+// eventField is a single piece of information about an event, e.g. its image
+type eventField struct {
+	name  string
+	value string
+}
 
-	// block function until time (delay) triggers
-	// if sendNotifications is faster than the delay, function blocks here until delay is over
-	// if sendNotifications takes longer than the delay, trigger already fired and no delay is added
-	<-timer.C
+// eventFields collects the information about an event which is shown in the notification
+func eventFields(event events.Message) []eventField {
+	var fields []eventField
 
+	add := func(name string, value string) {
+		if len(value) > 0 {
+			fields = append(fields, eventField{name: name, value: value})
+		}
+	}
+
+	// Check possible image and container name
+	add("ID", getActorID(event))
+	add("Image", getActorImage(event))
+	add("Image version", getActorImageVersion(event))
+	add("Name", getActorName(event))
+
+	// Get event timestamp
+	add("Time", time.Unix(event.Time, 0).Format(time.RFC1123Z))
+
+	// Append possible docker compose context
+	add("Docker compose context", event.Actor.Attributes["com.docker.compose.project.working_dir"])
+	add("Docker compose service", event.Actor.Attributes["com.docker.compose.service"])
+
+	return fields
 }
 
 func getActorID(event events.Message) string {
@@ -146,51 +157,91 @@ func getActorName(event events.Message) string {
 
 }
 
+// severity of an event, used by notifiers to pick colours, priorities etc.
+type severity int
+
+const (
+	severityInfo severity = iota
+	severityGood
+	severityWarning
+	severityCritical
+)
+
+func eventSeverity(event events.Message) severity {
+	// actions like "health_status: unhealthy" carry additional information after the colon
+	action, detail, _ := strings.Cut(string(event.Action), ":")
+	detail = strings.TrimSpace(detail)
+
+	switch action {
+	case "die", "oom", "kill":
+		return severityCritical
+	case "health_status":
+		switch detail {
+		case "unhealthy":
+			return severityCritical
+		case "healthy":
+			return severityGood
+		}
+	case "stop", "restart", "pause", "destroy":
+		return severityWarning
+	case "start", "unpause":
+		return severityGood
+	}
+	return severityInfo
+}
+
 func excludeEvent(event events.Message) bool {
-	// Checks if any of the exclusion criteria matches the event
+	// Checks if any of the exclusion rules or the exclusion expression matches the event
+	// The conditions of a rule are AND'ed, the rules are OR'ed
 
 	ActorID := getActorID(event)
 
 	// Convert the event (struct of type event.Message) to a flattend map
 	eventMap := structToFlatMap(event)
 
-	// Check for all exclude key -> value combinations if they match the event
-	for key, values := range glb_arguments.Exclude {
-		eventValue, keyExist := eventMap[key]
-
-		// Check if the exclusion key exists in the eventMap
-		if !keyExist {
-			logger.Debug().
-				Str("ActorID", ActorID).
-				Msgf("Exclusion key \"%s\" did not match", key)
-			return false
-		}
-
+	if r, matched := matchRules(glb_arguments.Exclude, eventMap); matched {
 		logger.Debug().
 			Str("ActorID", ActorID).
-			Msgf("Exclusion key \"%s\" matched, checking values", key)
+			Msgf("Event excluded based on exclusion setting \"%s\"", r)
+		return true
+	}
+	if excludeExpression != nil && matchExpression(excludeExpression, event) {
+		logger.Debug().
+			Str("ActorID", ActorID).
+			Msg("Event excluded based on exclusion expression")
+		return true
+	}
 
+	logger.Debug().
+		Str("ActorID", ActorID).
+		Msg("Event did not match any exclusion setting")
+	return false
+}
+
+func includeEvent(event events.Message) bool {
+	// Checks if any of the inclusion rules or the inclusion expression matches the event
+
+	ActorID := getActorID(event)
+
+	// Convert the event (struct of type event.Message) to a flattend map
+	eventMap := structToFlatMap(event)
+
+	if r, matched := matchRules(glb_arguments.Include, eventMap); matched {
 		logger.Debug().
 			Str("ActorID", ActorID).
-			Msgf("Event's value for key \"%s\" is \"%s\"", key, eventValue)
-
-		for _, value := range values {
-			// comparing the prefix to be able to filter actions like "exec_XXX: YYYY" which use a
-			// special, dynamic, syntax
-			// see https://github.com/moby/moby/blob/bf053be997f87af233919a76e6ecbd7d17390e62/api/types/events/events.go#L74-L81
-
-			if strings.HasPrefix(eventValue, value) {
-				logger.Debug().
-					Str("ActorID", ActorID).
-					Msgf("Event excluded based on exclusion setting \"%s=%s\"", key, value)
-				return true
-			}
-		}
+			Msgf("Event included based on inclusion setting \"%s\"", r)
+		return true
+	}
+	if includeExpression != nil && matchExpression(includeExpression, event) {
 		logger.Debug().
 			Str("ActorID", ActorID).
-			Msgf("Exclusion key \"%s\" matched, but values did not match", key)
+			Msg("Event included based on inclusion expression")
+		return true
 	}
 
+	logger.Debug().
+		Str("ActorID", ActorID).
+		Msg("Event did not match any inclusion setting")
 	return false
 }
 
@@ -204,16 +255,16 @@ func flattenMap(prefix string, m map[string]interface{}) map[string]string {
 			newKey = prefix + "." + k
 		}
 		// if the value is a map/struct itself, transverse it recursivly
-		switch k {
-		case "Actor", "Attributes":
-			nestedMap := v.(map[string]interface{})
-			for nk, nv := range flattenMap(newKey, nestedMap) {
+		// null values (e.g. an actor without attributes) are left out, like missing keys
+		switch value := v.(type) {
+		case map[string]interface{}:
+			for nk, nv := range flattenMap(newKey, value) {
 				flatMap[nk] = nv
 			}
-		case "time", "timeNano":
-			flatMap[newKey] = string(v.(json.Number))
-		default:
-			flatMap[newKey] = v.(string)
+		case json.Number:
+			flatMap[newKey] = value.String()
+		case string:
+			flatMap[newKey] = value
 		}
 	}
 	return flatMap
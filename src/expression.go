@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/google/cel-go/cel"
+)
+
+// Expressions use the Common Expression Language (CEL), see https://github.com/google/cel-spec
+// They are compiled once on startup, nil if not set
+var (
+	includeExpression cel.Program
+	excludeExpression cel.Program
+)
+
+// variables available in expressions, named like the keys of exclude rules
+var expressionVariables = []cel.EnvOption{
+	cel.Variable("Type", cel.StringType),
+	cel.Variable("Action", cel.StringType),
+	cel.Variable("Actor", cel.MapType(cel.StringType, cel.DynType)),
+	cel.Variable("scope", cel.StringType),
+	cel.Variable("time", cel.IntType),
+	cel.Variable("timeNano", cel.IntType),
+	// derived fields, like in templates
+	cel.Variable("ActorID", cel.StringType),
+	cel.Variable("ActorName", cel.StringType),
+	cel.Variable("ActorImage", cel.StringType),
+	cel.Variable("ActorImageVersion", cel.StringType),
+}
+
+func setupExpressions() {
+	// Compiles the expressions, so syntax errors are reported right away
+
+	var err error
+	includeExpression, err = compileExpression(glb_arguments.IncludeExpr)
+	if err != nil {
+		logger.Fatal().Err(err).Str("expression", glb_arguments.IncludeExpr).Msg("Invalid include expression")
+	}
+	excludeExpression, err = compileExpression(glb_arguments.ExcludeExpr)
+	if err != nil {
+		logger.Fatal().Err(err).Str("expression", glb_arguments.ExcludeExpr).Msg("Invalid exclude expression")
+	}
+}
+
+func compileExpression(text string) (cel.Program, error) {
+	if len(text) == 0 {
+		return nil, nil
+	}
+
+	env, err := cel.NewEnv(expressionVariables...)
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(text)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("expression has to return a bool, not %s", ast.OutputType())
+	}
+	return env.Program(ast)
+}
+
+// evaluates the expression for the event
+// Evaluation errors (e.g. accessing an attribute the event doesn't have) count as no match
+func matchExpression(program cel.Program, event events.Message) bool {
+	out, _, err := program.Eval(expressionActivation(event))
+	if err != nil {
+		logger.Debug().Err(err).Str("ActorID", getActorID(event)).Msg("Failed to evaluate expression, treating as no match")
+		return false
+	}
+	matched, ok := out.Value().(bool)
+	return ok && matched
+}
+
+func expressionActivation(event events.Message) map[string]interface{} {
+	attributes := event.Actor.Attributes
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+
+	return map[string]interface{}{
+		"Type":   string(event.Type),
+		"Action": string(event.Action),
+		"Actor": map[string]interface{}{
+			"ID":         event.Actor.ID,
+			"Attributes": attributes,
+		},
+		"scope":             event.Scope,
+		"time":              event.Time,
+		"timeNano":          event.TimeNano,
+		"ActorID":           getActorID(event),
+		"ActorName":         getActorName(event),
+		"ActorImage":        getActorImage(event),
+		"ActorImageVersion": getActorImageVersion(event),
+	}
+}
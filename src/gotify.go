@@ -1,28 +1,77 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 )
 
+type gotifyArgs struct {
+	Gotify      bool   `arg:"env:GOTIFY" default:"false" help:"Enable/Disable Gotify Notification (True/False)"`
+	GotifyURL   string `arg:"env:GOTIFY_URL" help:"URL of your Gotify server"`
+	GotifyToken secret `arg:"env:GOTIFY_TOKEN" help:"Gotify's App Token"`
+}
+
+var gotifyKind = notifierKind{
+	name: "Gotify",
+	fromArgs: func() (Notifier, bool) {
+		notifier := &gotifyNotifier{
+			name:  "Gotify",
+			URL:   glb_arguments.GotifyURL,
+			Token: glb_arguments.GotifyToken,
+		}
+		return notifier, glb_arguments.Gotify
+	},
+	new: func(name string) Notifier {
+		return &gotifyNotifier{name: name}
+	},
+}
+
+type gotifyNotifier struct {
+	name string
+	notifierOptions
+	URL   string `json:"url,omitempty"`
+	Token secret `json:"token,omitempty"`
+}
+
 type GotifyMessage struct {
 	Title   string `json:"title"`
 	Message string `json:"message"`
 }
 
-func sendGotify(message string, title string) {
+func (g *gotifyNotifier) Name() string {
+	return g.name
+}
+
+func (g *gotifyNotifier) Validate() error {
+	if len(g.URL) == 0 {
+		return errors.New("Gotify URL required")
+	}
+	if len(g.Token) == 0 {
+		return errors.New("Gotify APP token required")
+	}
+	return nil
+}
+
+func (g *gotifyNotifier) Describe() []setting {
+	return []setting{
+		{key: "GotifyURL", value: g.URL},
+		{key: "GotifyToken", value: g.Token.String()},
+	}
+}
+
+func (g *gotifyNotifier) Send(ctx context.Context, n Notification) error {
 	// Send a message to Gotify
 
 	m := GotifyMessage{
-		Title:   title,
-		Message: message,
+		Title:   n.Title,
+		Message: n.Message,
 	}
 
 	messageJSON, err := json.Marshal(m)
 	if err != nil {
-		logger.Error().Err(err).Str("reporter", "Gotify").Msg("Faild to marshal JSON")
-		return
+		return err
 	}
 
-	sendhttpMessage("Gotify", glb_arguments.GotifyURL+"/message?token="+glb_arguments.GotifyToken, messageJSON)
-
+	return sendhttpMessage(ctx, g, g.URL+"/message?token="+string(g.Token), messageJSON)
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/docker/client"
+)
+
+// true while the monitor is subscribed to the docker event stream
+var streamConnected atomic.Bool
+
+// time (UnixNano) the event loop started processing the current event, 0 if idle
+var busySince atomic.Int64
+
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	// The monitor is unhealthy if processing a single event takes longer than the threshold,
+	// e.g. because the event loop is blocked
+
+	if since := busySince.Load(); since > 0 {
+		busy := time.Since(time.Unix(0, since))
+		if busy > glb_arguments.HealthTimeout {
+			http.Error(w, "event loop stuck for "+busy.Round(time.Second).String(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+func readyHandler(cli *client.Client) http.HandlerFunc {
+	// The monitor is ready if it's subscribed to the event stream and the docker API is reachable
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !streamConnected.Load() {
+			http.Error(w, "not connected to docker event stream", http.StatusServiceUnavailable)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		defer cancel()
+		if _, err := cli.Ping(ctx); err != nil {
+			http.Error(w, "docker API not reachable: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	}
+}
+
+func runHealthcheck() {
+	// Queries the health endpoint of a running monitor and exits accordingly
+	// Used for the Dockerfile HEALTHCHECK, as there is no curl/wget in the image
+
+	if len(glb_arguments.ListenAddress) == 0 {
+		// nothing to check without HTTP listener
+		os.Exit(0)
+	}
+
+	host, port, err := net.SplitHostPort(glb_arguments.ListenAddress)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid listen address:", err)
+		os.Exit(1)
+	}
+	if len(host) == 0 || net.ParseIP(host).IsUnspecified() {
+		host = "127.0.0.1"
+	}
+
+	netClient := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+	resp, err := netClient.Get("http://" + net.JoinHostPort(host, port) + "/healthz")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintln(os.Stderr, "unhealthy, status code", resp.StatusCode)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/docker/docker/client"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func startHTTPServer(cli *client.Client) {
+	// Serves the monitor's own endpoints, runs as goroutine
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", healthHandler)
+	mux.HandleFunc("/readyz", readyHandler(cli))
+
+	logger.Info().Str("address", glb_arguments.ListenAddress).Msg("Starting HTTP listener")
+
+	err := http.ListenAndServe(glb_arguments.ListenAddress, mux)
+	logger.Fatal().Err(err).Msg("HTTP listener failed")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+// labels of containers to control monitoring from the compose file
+// Docker adds the labels of a container to the attributes of its events
+const (
+	labelPrefix    = "docker-event-monitor."
+	labelEnable    = labelPrefix + "enable"
+	labelActions   = labelPrefix + "actions"
+	labelNotifiers = labelPrefix + "notifiers"
+)
+
+func excludedByLabels(event events.Message) bool {
+	// Checks the enable and actions labels of the event's actor
+	// In label-enable mode, only containers labelled enable=true are reported
+
+	ActorID := getActorID(event)
+
+	value, labelled := event.Actor.Attributes[labelEnable]
+	enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+	if labelled && err != nil {
+		logger.Warn().
+			Str("ActorID", ActorID).
+			Msgf("Ignoring invalid label %s=\"%s\"", labelEnable, value)
+		labelled = false
+	}
+	if labelled && !enabled {
+		logger.Debug().
+			Str("ActorID", ActorID).
+			Msgf("Event excluded based on label %s", labelEnable)
+		return true
+	}
+	// other event types (e.g. networks or images) don't carry the labels of containers
+	if glb_arguments.LabelEnable && !labelled && event.Type == events.ContainerEventType {
+		logger.Debug().
+			Str("ActorID", ActorID).
+			Msgf("Event excluded, container is not labelled %s=true", labelEnable)
+		return true
+	}
+
+	if actions := splitLabel(event.Actor.Attributes[labelActions]); len(actions) > 0 {
+		if !matchAction(actions, string(event.Action)) {
+			logger.Debug().
+				Str("ActorID", ActorID).
+				Msgf("Event excluded based on label %s", labelActions)
+			return true
+		}
+	}
+	return false
+}
+
+func labelledNotifiers(event events.Message) ([]Notifier, bool) {
+	// Returns the notifiers set by label, false if the label isn't set
+	// Unknown notifiers are skipped, as the label might be meant for another monitor
+	// If none of them exists, the event is routed as if the label wasn't set
+
+	names := splitLabel(event.Actor.Attributes[labelNotifiers])
+	if len(names) == 0 {
+		return nil, false
+	}
+
+	var selected []Notifier
+	for _, name := range names {
+		notifier := findNotifier(name)
+		if notifier == nil {
+			logger.Warn().
+				Str("ActorID", getActorID(event)).
+				Str("reporter", name).
+				Msgf("Label %s uses unknown notifier", labelNotifiers)
+			continue
+		}
+		selected = append(selected, notifier)
+	}
+	if len(selected) == 0 {
+		logger.Warn().
+			Str("ActorID", getActorID(event)).
+			Msgf("None of the notifiers of label %s exists, using the routes instead", labelNotifiers)
+		return nil, false
+	}
+	return selected, true
+}
+
+// splits a comma separated label value, ignoring whitespace and empty values
+func splitLabel(value string) stringList {
+	var values stringList
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			values = append(values, v)
+		}
+	}
+	return values
+}
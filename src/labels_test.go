@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+func labelledEvent(eventType string, action string, labels map[string]string) events.Message {
+	return events.Message{
+		Type:   events.Type(eventType),
+		Action: events.Action(action),
+		Actor:  events.Actor{ID: "0123456789abcdef", Attributes: labels},
+	}
+}
+
+func TestExcludedByLabels(t *testing.T) {
+	defer func() { glb_arguments = args{} }()
+
+	tests := []struct {
+		labelEnable bool
+		event       events.Message
+		want        bool
+	}{
+		{false, labelledEvent("container", "die", nil), false},
+		{false, labelledEvent("container", "die", map[string]string{labelEnable: "false"}), true},
+		{false, labelledEvent("container", "die", map[string]string{labelEnable: "true"}), false},
+		// invalid values are ignored
+		{false, labelledEvent("container", "die", map[string]string{labelEnable: "nope"}), false},
+		{false, labelledEvent("container", "start", map[string]string{labelActions: "die, oom,health_status"}), true},
+		{false, labelledEvent("container", "oom", map[string]string{labelActions: "die, oom,health_status"}), false},
+		{false, labelledEvent("container", "health_status: unhealthy", map[string]string{labelActions: "die,oom,health_status"}), false},
+		{true, labelledEvent("container", "die", nil), true},
+		{true, labelledEvent("container", "die", map[string]string{labelEnable: "nope"}), true},
+		{true, labelledEvent("container", "die", map[string]string{labelEnable: "true"}), false},
+		{true, labelledEvent("container", "die", map[string]string{labelEnable: "false"}), true},
+		// other event types don't carry the labels of containers
+		{true, labelledEvent("network", "connect", nil), false},
+	}
+
+	for _, test := range tests {
+		glb_arguments.LabelEnable = test.labelEnable
+		if got := excludedByLabels(test.event); got != test.want {
+			t.Errorf("label-enable=%v, %s %v: excluded = %v, want %v", test.labelEnable, test.event.Action, test.event.Actor.Attributes, got, test.want)
+		}
+	}
+}
+
+func TestLabelledNotifiers(t *testing.T) {
+	mail := &mailNotifier{name: "Mail"}
+	gotify := &gotifyNotifier{name: "Gotify"}
+	notifiers = []Notifier{mail, gotify}
+	defer func() { notifiers = nil }()
+
+	tests := []struct {
+		label string
+		want  []Notifier
+	}{
+		{"", []Notifier{mail, gotify}},
+		{"mail", []Notifier{mail}},
+		{"MAIL, unknown", []Notifier{mail}},
+		{"gotify,mail", []Notifier{gotify, mail}},
+		// if none of the notifiers exists, the routes are used
+		{"unknown,other", []Notifier{mail, gotify}},
+	}
+
+	for _, test := range tests {
+		event := labelledEvent("container", "die", map[string]string{labelNotifiers: test.label})
+		got := routeEvent(&event)
+		if len(got) != len(test.want) {
+			t.Errorf("label %q: routed to %d notifiers, want %d", test.label, len(got), len(test.want))
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("label %q: notifier %d is %s, want %s", test.label, i, got[i].Name(), test.want[i].Name())
+			}
+		}
+	}
+}
@@ -1,12 +1,92 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
 	"net/smtp"
 	"strconv"
 	"strings"
 	"time"
 )
 
+type mailArgs struct {
+	Mail         bool   `arg:"env:MAIL" default:"false" help:"Enable/Disable Mail (SMTP) Notification (True/False)"`
+	MailFrom     string `arg:"env:MAIL_FROM" help:"your.username@provider.com"`
+	MailTo       string `arg:"env:MAIL_TO" help:"recipient@provider.com"`
+	MailUser     string `arg:"env:MAIL_USER" help:"SMTP Username"`
+	MailPassword secret `arg:"env:MAIL_PASSWORD" help:"SMTP Password"`
+	MailPort     int    `arg:"env:MAIL_PORT" help:"SMTP Port (default: 587)"`
+	MailHost     string `arg:"env:MAIL_HOST" help:"SMTP Host"`
+}
+
+var mailKind = notifierKind{
+	name: "Mail",
+	fromArgs: func() (Notifier, bool) {
+		notifier := &mailNotifier{
+			name:     "Mail",
+			From:     glb_arguments.MailFrom,
+			To:       glb_arguments.MailTo,
+			User:     glb_arguments.MailUser,
+			Password: glb_arguments.MailPassword,
+			Port:     glb_arguments.MailPort,
+			Host:     glb_arguments.MailHost,
+		}
+		return notifier, glb_arguments.Mail
+	},
+	new: func(name string) Notifier {
+		return &mailNotifier{name: name}
+	},
+}
+
+type mailNotifier struct {
+	name string
+	notifierOptions
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	User     string `json:"user,omitempty"`
+	Password secret `json:"password,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Host     string `json:"host,omitempty"`
+}
+
+func (m *mailNotifier) Name() string {
+	return m.name
+}
+
+func (m *mailNotifier) Validate() error {
+	if len(m.User) == 0 {
+		return errors.New("SMTP username required")
+	}
+	if len(m.To) == 0 {
+		return errors.New("recipient address required")
+	}
+	if len(m.From) == 0 {
+		m.From = m.User
+	}
+	if m.Port == 0 {
+		m.Port = 587
+	}
+	if len(m.Password) == 0 {
+		return errors.New("SMTP Password required")
+	}
+	if len(m.Host) == 0 {
+		return errors.New("SMTP host address required")
+	}
+	return nil
+}
+
+func (m *mailNotifier) Describe() []setting {
+	return []setting{
+		{key: "MailFrom", value: m.From},
+		{key: "MailTo", value: m.To},
+		{key: "MailHost", value: m.Host},
+		{key: "MailUser", value: m.User},
+		{key: "Port", value: strconv.Itoa(m.Port)},
+	}
+}
+
 func buildEMail(timestamp time.Time, from string, to []string, subject string, body string) string {
 	var msg strings.Builder
 	msg.WriteString("From: " + from + "\r\n")
@@ -19,27 +99,83 @@ func buildEMail(timestamp time.Time, from string, to []string, subject string, b
 	return msg.String()
 }
 
-func sendMail(timestamp time.Time, message string, title string) {
+func (m *mailNotifier) Send(ctx context.Context, n Notification) error {
 
-	from := glb_arguments.MailFrom
-	to := []string{glb_arguments.MailTo}
-	username := glb_arguments.MailUser
-	password := glb_arguments.MailPassword
+	from := m.From
+	to := []string{m.To}
+	username := m.User
+	password := string(m.Password)
 
-	host := glb_arguments.MailHost
-	port := strconv.Itoa(glb_arguments.MailPort)
+	host := m.Host
+	port := strconv.Itoa(m.Port)
 	address := host + ":" + port
 
-	subject := title
-	body := message
+	subject := n.Title
+	body := n.Message
 
-	mail := buildEMail(timestamp, from, to, subject, body)
+	mail := buildEMail(n.Timestamp, from, to, subject, body)
 
 	auth := smtp.PlainAuth("", username, password, host)
 
-	err := smtp.SendMail(address, auth, from, to, []byte(mail))
+	start := time.Now()
+	err := sendMail(ctx, address, host, auth, from, to, []byte(mail))
+	observeDelivery(m.Name(), start, 0, err)
+	return err
+}
+
+// maximum time to deliver a mail, smtp.SendMail itself has no timeout
+const mailTimeout = 30 * time.Second
+
+// sendMail works like smtp.SendMail, but gives up after mailTimeout or once ctx is done
+func sendMail(ctx context.Context, address string, host string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, mailTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, host)
 	if err != nil {
-		logger.Error().Err(err).Str("reporter", "Mail").Msg("")
-		return
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if ok, _ := c.Extension("AUTH"); ok && auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := c.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
 	}
+	return c.Quit()
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSendMailTimesOut(t *testing.T) {
+	// the server accepts the connection, but never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = sendMail(ctx, listener.Addr().String(), "127.0.0.1", nil, "from@example.com", []string{"to@example.com"}, []byte("test"))
+	if err == nil {
+		t.Fatal("expected a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("sendMail took %s, expected to give up after the deadline", elapsed)
+	}
+	if retryable, _ := isRetryable(err); !retryable {
+		t.Errorf("expected the timeout to be retryable, got %v", err)
+	}
+}
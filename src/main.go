@@ -1,13 +1,12 @@
 package main
 
 import (
-	"context"
 	"os"
+	"reflect"
 	"strings"
 	"time"
 
 	"github.com/alexflint/go-arg"
-	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
 
@@ -15,31 +14,44 @@ import (
 )
 
 type args struct {
-	Pushover          bool                `arg:"env:PUSHOVER" default:"false" help:"Enable/Disable Pushover Notification (True/False)"`
-	PushoverAPIToken  string              `arg:"env:PUSHOVER_APITOKEN" help:"Pushover's API Token/Key"`
-	PushoverUserKey   string              `arg:"env:PUSHOVER_USER" help:"Pushover's User Key"`
-	Gotify            bool                `arg:"env:GOTIFY" default:"false" help:"Enable/Disable Gotify Notification (True/False)"`
-	GotifyURL         string              `arg:"env:GOTIFY_URL" help:"URL of your Gotify server"`
-	GotifyToken       string              `arg:"env:GOTIFY_TOKEN" help:"Gotify's App Token"`
-	Mail              bool                `arg:"env:MAIL" default:"false" help:"Enable/Disable Mail (SMTP) Notification (True/False)"`
-	MailFrom          string              `arg:"env:MAIL_FROM" help:"your.username@provider.com"`
-	MailTo            string              `arg:"env:MAIL_TO" help:"recipient@provider.com"`
-	MailUser          string              `arg:"env:MAIL_USER" help:"SMTP Username"`
-	MailPassword      string              `arg:"env:MAIL_PASSWORD" help:"SMTP Password"`
-	MailPort          int                 `arg:"env:MAIL_PORT" default:"587" help:"SMTP Port"`
-	MailHost          string              `arg:"env:MAIL_HOST" help:"SMTP Host"`
-	Mattermost        bool                `arg:"env:MATTERMOST" default:"false" help:"Enable/Disable Mattermost Notification (True/False)"`
-	MattermostURL     string              `arg:"env:MATTERMOST_URL" help:"URL of your Mattermost incoming webhook"`
-	MattermostChannel string              `arg:"env:MATTERMOST_CHANNEL" help:"Mattermost channel to post in"`
-	MattermostUser    string              `arg:"env:MATTERMOST_USER" default:"Docker Event Monitor" help:"Mattermost user to post as"`
-	Delay             time.Duration       `arg:"env:DELAY" default:"500ms" help:"Delay before next message is send"`
-	FilterStrings     []string            `arg:"env:FILTER,--filter,separate" help:"Filter docker events using Docker syntax."`
-	Filter            map[string][]string `arg:"-"`
-	ExcludeStrings    []string            `arg:"env:EXCLUDE,--exclude,separate" help:"Exclude docker events using Docker syntax."`
-	Exclude           map[string][]string `arg:"-"`
-	LogLevel          string              `arg:"env:LOG_LEVEL" default:"info" help:"Set log level. Use debug for more logging."`
-	ServerTag         string              `arg:"env:SERVER_TAG" help:"Prefix to include in the title of notifications. Useful when running docker-event-monitors on multiple machines."`
-	Version           bool                `arg:"-v" help:"Print version information."`
+	pushoverArgs
+	gotifyArgs
+	mailArgs
+	mattermostArgs
+	slackArgs
+	discordArgs
+	telegramArgs
+	matrixArgs
+	teamsArgs
+	ntfyArgs
+	webhookArgs
+	Config          string              `arg:"env:CONFIG" help:"Path to a YAML or TOML configuration file. Environment variables and flags override its settings."`
+	Delay           time.Duration       `arg:"env:DELAY" help:"Minimum delay between two messages of a notifier"`
+	FilterStrings   []string            `arg:"env:FILTER,--filter,separate" help:"Filter docker events using Docker syntax."`
+	Filter          map[string][]string `arg:"-"`
+	ExcludeStrings  []string            `arg:"env:EXCLUDE,--exclude,separate" help:"Exclude docker events, e.g. Action=exec_ or Actor.Attributes.name*=*-tmp"`
+	Exclude         []rule              `arg:"-"`
+	IncludeStrings  []string            `arg:"env:INCLUDE,--include,separate" help:"Only report docker events matching one of these conditions, uses the same syntax as exclude"`
+	Include         []rule              `arg:"-"`
+	ExcludeExpr     string              `arg:"env:EXCLUDE_EXPR" help:"Exclude docker events matching this CEL expression"`
+	IncludeExpr     string              `arg:"env:INCLUDE_EXPR" help:"Only report docker events matching this CEL expression (or an include rule)"`
+	LabelEnable     bool                `arg:"--label-enable,env:LABEL_ENABLE" help:"Only report containers labelled docker-event-monitor.enable=true"`
+	LogLevel        string              `arg:"env:LOG_LEVEL" default:"info" help:"Set log level. Use debug for more logging."`
+	ServerTag       string              `arg:"env:SERVER_TAG" help:"Prefix to include in the title of notifications. Useful when running docker-event-monitors on multiple machines."`
+	Retries         int                 `arg:"env:RETRIES" help:"Number of retries if sending a notification failed temporarily"`
+	RetryDelay      time.Duration       `arg:"env:RETRY_DELAY" help:"Delay before the first retry, doubled for each further retry"`
+	OutboxDir       string              `arg:"env:OUTBOX_DIR" help:"Directory to store undelivered notifications in. They are delivered once the notifier is reachable again."`
+	OutboxInterval  time.Duration       `arg:"env:OUTBOX_INTERVAL" default:"1m" help:"Interval to retry delivering notifications from the outbox"`
+	QueueSize       int                 `arg:"env:QUEUE_SIZE" default:"100" help:"Maximum number of pending notifications per notifier"`
+	QueueOverflow   string              `arg:"env:QUEUE_OVERFLOW" default:"drop-oldest" help:"What to do if a notifier's queue is full: drop-oldest, drop-newest or block"`
+	TitleTemplate   string              `arg:"env:TITLE_TEMPLATE" help:"Go text/template for the title of event notifications"`
+	MessageTemplate string              `arg:"env:MESSAGE_TEMPLATE" help:"Go text/template for the message of event notifications"`
+	ListenAddress   string              `arg:"env:LISTEN_ADDRESS" help:"Address of the HTTP listener exposing /metrics, /healthz and /readyz, e.g. :8080. Disabled if empty."`
+	HealthTimeout   time.Duration       `arg:"env:HEALTH_TIMEOUT" default:"1m" help:"Report unhealthy if processing a single event takes longer"`
+	StateFile       string              `arg:"env:STATE_FILE,--state-file" help:"File to store the timestamp of the last processed event. Events missed while the monitor was not running are replayed on startup."`
+	Healthcheck     bool                `help:"Check the health of a running monitor via its HTTP listener and exit. Used for the Docker HEALTHCHECK."`
+	ShowSecrets     bool                `arg:"--show-secrets,env:SHOW_SECRETS" help:"Show tokens, passwords etc. in logs and the startup message. For debugging only!"`
+	Version         bool                `arg:"-v" help:"Print version information."`
 }
 
 // Creating a global logger
@@ -57,62 +69,65 @@ var (
 	branch  string = "n/a"
 )
 
-func init() {
+func setup() {
+	// Parses the run-time arguments and sets everything up
+	// Not done in init(), so tests can run without the arguments of the monitor
+
+	// preliminary logger, until the log level is known
+	configureLogger("info")
 	parseArgs()
 	configureLogger(glb_arguments.LogLevel)
 
-	if glb_arguments.Pushover {
-		if len(glb_arguments.PushoverAPIToken) == 0 {
-			logger.Fatal().Msg("Pushover enabled. Pushover API token required!")
-		}
-		if len(glb_arguments.PushoverUserKey) == 0 {
-			logger.Fatal().Msg("Pushover enabled. Pushover user key required!")
-		}
-	}
-	if glb_arguments.Gotify {
-		if len(glb_arguments.GotifyURL) == 0 {
-			logger.Fatal().Msg("Gotify enabled. Gotify URL required!")
-		}
-		if len(glb_arguments.GotifyToken) == 0 {
-			logger.Fatal().Msg("Gotify enabled. Gotify APP token required!")
-		}
-	}
-	if glb_arguments.Mail {
-		if len(glb_arguments.MailUser) == 0 {
-			logger.Fatal().Msg("E-Mail notification enabled. SMTP username required!")
-		}
-		if len(glb_arguments.MailTo) == 0 {
-			logger.Fatal().Msg("E-Mail notification enabled. Recipient address required!")
-		}
-		if len(glb_arguments.MailFrom) == 0 {
-			glb_arguments.MailFrom = glb_arguments.MailUser
-		}
-		if len(glb_arguments.MailPassword) == 0 {
-			logger.Fatal().Msg("E-Mail notification enabled. SMTP Password required!")
-		}
-		if len(glb_arguments.MailHost) == 0 {
-			logger.Fatal().Msg("E-Mail notification enabled. SMTP host address required!")
-		}
+	// tickers panic on non-positive intervals, and the health check would fail on every event
+	if glb_arguments.OutboxInterval <= 0 {
+		logger.Fatal().Str("outboxInterval", glb_arguments.OutboxInterval.String()).Msg("Outbox interval must be positive")
 	}
-	if glb_arguments.Mattermost {
-		if len(glb_arguments.MattermostURL) == 0 {
-			logger.Fatal().Msg("Mattermost enabled. Mattermost URL required!")
-		}
+	if glb_arguments.HealthTimeout <= 0 {
+		logger.Fatal().Str("healthTimeout", glb_arguments.HealthTimeout.String()).Msg("Health timeout must be positive")
 	}
+
+	setupNotifiers()
+	setupRoutes()
+	setupQuietHours()
+	setupQueues()
+	setupTemplates()
+	setupExpressions()
 }
 
 func main() {
+	setup()
+
 	// if the -v flag was set, print version information and exit
 	if glb_arguments.Version {
 		printVersion()
 	}
 
+	// if the --healthcheck flag was set, check the health of the running monitor and exit
+	if glb_arguments.Healthcheck {
+		runHealthcheck()
+	}
+
 	// log all supplied arguments
 	logArguments()
 
+	// prepare notifiers which need it, e.g. join chat rooms
+	startNotifiers()
+
+	// start delivering notifications in the background
+	startQueues()
+
+	// deliver notifications which could not be delivered before
+	if outboxEnabled() {
+		go processOutbox()
+	}
+
 	timestamp := time.Now()
 	startup_message := buildStartupMessage(timestamp)
-	sendNotifications(timestamp, startup_message, "Starting docker event monitor")
+	sendNotifications(Notification{
+		Timestamp: timestamp,
+		Title:     "Starting docker event monitor",
+		Message:   startup_message,
+	})
 
 	filterArgs := filters.NewArgs()
 	for key, values := range glb_arguments.Filter {
@@ -127,33 +142,57 @@ func main() {
 	}
 	defer cli.Close()
 
-	// receives events from the channel
-	event_chan, errs := cli.Events(context.Background(), types.EventsOptions{Filters: filterArgs})
-
-	for {
-		select {
-		case err := <-errs:
-			logger.Fatal().Err(err).Msg("")
-		case event := <-event_chan:
-			// if logging level is debug, log the event
-			logger.Debug().
-				Interface("event", event).Msg("")
-
-			// Check if event should be exlcuded from reporting
-			if len(glb_arguments.Exclude) > 0 {
-				logger.Debug().Msg("Performing check for event exclusion")
-				if excludeEvent(event) {
-					break //breaks out of the select and waits for the next event to arrive
-				}
-			}
-			processEvent(event)
-		}
+	if len(glb_arguments.ListenAddress) > 0 {
+		go startHTTPServer(cli)
 	}
+
+	// report events which happend since the last run of the monitor
+	if len(glb_arguments.StateFile) > 0 {
+		replayEvents(cli, filterArgs)
+	}
+
+	// receive and process events, reconnects to the event stream if necessary
+	watchEvents(cli, filterArgs)
 }
 
 func parseArgs() {
+	// Defaults of settings which can be disabled by setting them to zero
+	// They can't be set via the 'default' tag, as go-arg would replace a zero value
+	// from the configuration file by it
+	glb_arguments.Delay = 500 * time.Millisecond
+	glb_arguments.Retries = 3
+	glb_arguments.RetryDelay = time.Second
+
+	// Settings of the configuration file are used as defaults,
+	// so environment variables and flags can override them
+	if path := configPath(); len(path) > 0 {
+		config, err := loadConfig(path)
+		if err != nil {
+			logger.Fatal().Err(err).Str("config", path).Msg("Failed to load configuration file")
+		}
+		glb_config = config
+		applyConfig(config)
+	}
+
 	parser := arg.MustParse(&glb_arguments)
 
+	// read secrets from files, e.g. Docker secrets
+	if err := loadSecretFiles(reflect.ValueOf(&glb_arguments).Elem()); err != nil {
+		logger.Fatal().Err(err).Msg("Failed to load secrets")
+	}
+
+	// slices can't be used as defaults, so they are only taken from the configuration file
+	// if no filters were supplied otherwise
+	if len(glb_arguments.FilterStrings) == 0 {
+		glb_arguments.FilterStrings = glb_config.Filter
+	}
+	if len(glb_arguments.ExcludeStrings) == 0 {
+		glb_arguments.ExcludeStrings = glb_config.Exclude
+	}
+	if len(glb_arguments.IncludeStrings) == 0 {
+		glb_arguments.IncludeStrings = glb_config.Include
+	}
+
 	// Parse (include) filters
 	glb_arguments.Filter = make(map[string][]string)
 
@@ -168,19 +207,24 @@ func parseArgs() {
 	}
 
 	// Parse exclude filters
-	glb_arguments.Exclude = make(map[string][]string)
+	glb_arguments.Exclude = parseRules(parser, glb_arguments.ExcludeStrings)
 
-	for _, exclude := range glb_arguments.ExcludeStrings {
-		pos := strings.Index(exclude, "=")
-		if pos == -1 {
-			parser.Fail("each filter should be of the form key=value")
+	// Parse include filters
+	glb_arguments.Include = parseRules(parser, glb_arguments.IncludeStrings)
+}
+
+// parses exclude/include rules, keeping their order
+func parseRules(parser *arg.Parser, ruleStrings []string) []rule {
+	var rules []rule
+
+	for _, ruleString := range ruleStrings {
+		r, err := parseRule(ruleString)
+		if err != nil {
+			parser.Fail(err.Error())
 		}
-		//trim whitespaces
-		key := strings.TrimSpace(exclude[:pos])
-		val := exclude[pos+1:]
-		glb_arguments.Exclude[key] = append(glb_arguments.Exclude[key], val)
+		rules = append(rules, r)
 	}
-
+	return rules
 }
 
 func configureLogger(LogLevel string) {
@@ -189,14 +233,14 @@ func configureLogger(LogLevel string) {
 
 	// Change logging level when debug flag is set
 	if LogLevel == "debug" {
-		logger = zerolog.New(os.Stdout).
+		logger = zerolog.New(redactingWriter{os.Stdout}).
 			Level(zerolog.DebugLevel).
 			With().
 			Timestamp().
 			Str("service", "docker event monitor").
 			Logger()
 	} else {
-		logger = zerolog.New(os.Stdout).
+		logger = zerolog.New(redactingWriter{os.Stdout}).
 			Level(zerolog.InfoLevel).
 			With().
 			Str("service", "docker event monitor").
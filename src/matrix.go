@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type matrixArgs struct {
+	Matrix           bool   `arg:"env:MATRIX" default:"false" help:"Enable/Disable Matrix Notification (True/False)"`
+	MatrixHomeserver string `arg:"env:MATRIX_HOMESERVER" help:"URL of your Matrix homeserver"`
+	MatrixToken      secret `arg:"env:MATRIX_TOKEN" help:"Access token of the Matrix user to post as"`
+	MatrixRoom       string `arg:"env:MATRIX_ROOM" help:"Matrix room ID (or alias, if joining the room) to post in"`
+	MatrixJoin       bool   `arg:"env:MATRIX_JOIN" help:"Join the Matrix room on startup"`
+}
+
+var matrixKind = notifierKind{
+	name: "Matrix",
+	fromArgs: func() (Notifier, bool) {
+		notifier := &matrixNotifier{
+			name:       "Matrix",
+			Homeserver: glb_arguments.MatrixHomeserver,
+			Token:      glb_arguments.MatrixToken,
+			Room:       glb_arguments.MatrixRoom,
+			Join:       glb_arguments.MatrixJoin,
+		}
+		return notifier, glb_arguments.Matrix
+	},
+	new: func(name string) Notifier {
+		return &matrixNotifier{name: name}
+	},
+}
+
+type matrixNotifier struct {
+	name string
+	notifierOptions
+	Homeserver string `json:"homeserver,omitempty"`
+	Token      secret `json:"token,omitempty"`
+	Room       string `json:"room,omitempty"`
+	Join       bool   `json:"join,omitempty"`
+	// ID of the room, resolved from the alias when joining
+	// Empty until the room was joined, if joining is enabled
+	roomID    string
+	joinMutex sync.Mutex
+}
+
+// MatrixMessage is the content of a m.room.message event
+// see https://spec.matrix.org/latest/client-server-api/#mroommessage
+type MatrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format"`
+	FormattedBody string `json:"formatted_body"`
+}
+
+// body of the homeserver's error responses
+type matrixError struct {
+	// milliseconds to wait before trying again, set if rate limited
+	RetryAfterMs int `json:"retry_after_ms"`
+}
+
+func (m *matrixNotifier) Name() string {
+	return m.name
+}
+
+func (m *matrixNotifier) Validate() error {
+	if len(m.Homeserver) == 0 {
+		return errors.New("Matrix homeserver required")
+	}
+	if len(m.Token) == 0 {
+		return errors.New("Matrix access token required")
+	}
+	if len(m.Room) == 0 {
+		return errors.New("Matrix room required")
+	}
+	if !m.Join && !strings.HasPrefix(m.Room, "!") {
+		return errors.New("Matrix room has to be a room ID (!abc:example.com), aliases are only supported when joining the room")
+	}
+	m.Homeserver = strings.TrimRight(m.Homeserver, "/")
+	if !m.Join {
+		m.roomID = m.Room
+	}
+	return nil
+}
+
+func (m *matrixNotifier) Describe() []setting {
+	return []setting{
+		{key: "MatrixHomeserver", value: m.Homeserver},
+		{key: "MatrixToken", value: m.Token.String()},
+		{key: "MatrixRoom", value: m.Room},
+		{key: "MatrixJoin", value: strconv.FormatBool(m.Join)},
+	}
+}
+
+// Start joins the room, if enabled
+// If joining fails, it's tried again before sending the next notification
+func (m *matrixNotifier) Start(ctx context.Context) error {
+	_, err := m.room(ctx)
+	return err
+}
+
+// returns the ID of the room to post in, joins the room if it wasn't joined yet
+func (m *matrixNotifier) room(ctx context.Context) (string, error) {
+	m.joinMutex.Lock()
+	defer m.joinMutex.Unlock()
+
+	if len(m.roomID) > 0 {
+		return m.roomID, nil
+	}
+
+	// see https://spec.matrix.org/latest/client-server-api/#post_matrixclientv3joinroomidoralias
+	body, err := sendhttpRequest(ctx, m, httpRequest{
+		method:  http.MethodPost,
+		address: m.Homeserver + "/_matrix/client/v3/join/" + url.PathEscape(m.Room),
+		header:  m.header(),
+		body:    []byte("{}"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to join room: %w", err)
+	}
+
+	var joined struct {
+		RoomID string `json:"room_id"`
+	}
+	if err := json.Unmarshal(body, &joined); err != nil || len(joined.RoomID) == 0 {
+		return "", fmt.Errorf("failed to join room: unexpected response %s", string(body))
+	}
+	m.roomID = joined.RoomID
+
+	logger.Info().Str("reporter", m.Name()).Str("room", m.roomID).Msg("Joined Matrix room")
+	return m.roomID, nil
+}
+
+// Send a message to a Matrix room
+func (m *matrixNotifier) Send(ctx context.Context, n Notification) error {
+
+	roomID, err := m.room(ctx)
+	if err != nil {
+		return err
+	}
+
+	// m.notice is meant for automated messages, clients show them less prominent and bots don't answer them
+	message := MatrixMessage{
+		MsgType:       "m.notice",
+		Body:          n.Title + "\n" + n.Message,
+		Format:        "org.matrix.custom.html",
+		FormattedBody: "<strong>" + html.EscapeString(n.Title) + "</strong><br>" + strings.ReplaceAll(html.EscapeString(n.Message), "\n", "<br>"),
+	}
+
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	// see https://spec.matrix.org/latest/client-server-api/#put_matrixclientv3roomsroomidsendeventtypetxnid
+	_, err = sendhttpRequest(ctx, m, httpRequest{
+		method:     http.MethodPut,
+		address:    m.Homeserver + "/_matrix/client/v3/rooms/" + url.PathEscape(roomID) + "/send/m.room.message/" + matrixTxnID(n),
+		header:     m.header(),
+		body:       messageJSON,
+		retryAfter: matrixRetryAfter,
+	})
+	return err
+}
+
+// the homeserver tells the time to wait in the body of rate limited responses
+func matrixRetryAfter(body []byte) time.Duration {
+	var apiErr matrixError
+	if json.Unmarshal(body, &apiErr) != nil {
+		return 0
+	}
+	return time.Duration(apiErr.RetryAfterMs) * time.Millisecond
+}
+
+func (m *matrixNotifier) header() http.Header {
+	return http.Header{"Authorization": {"Bearer " + string(m.Token)}}
+}
+
+// matrixTxnID derives the transaction ID from the notification, so the homeserver
+// recognizes retries (including the ones from the outbox) and doesn't post them twice
+func matrixTxnID(n Notification) string {
+	return "dem-" + notificationID(n)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMatrixJoinsLazily(t *testing.T) {
+	var joinAttempts atomic.Int32
+	var sentTo atomic.Value
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/join/"):
+			// the homeserver is unavailable on startup
+			if joinAttempts.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"room_id":"!abc:example.com"}`))
+		case strings.Contains(r.URL.Path, "/send/"):
+			sentTo.Store(r.URL.EscapedPath())
+			w.Write([]byte(`{"event_id":"$1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	matrix := &matrixNotifier{name: "Matrix", Homeserver: server.URL, Token: "token", Room: "#ops:example.com", Join: true}
+	if err := matrix.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	err := matrix.Start(context.Background())
+	if retryable, _ := isRetryable(err); err == nil || !retryable {
+		t.Fatalf("expected a retryable error on startup, got %v", err)
+	}
+
+	if err := matrix.Send(context.Background(), Notification{Timestamp: time.Now(), Title: "title", Message: "message"}); err != nil {
+		t.Fatal(err)
+	}
+	if path, _ := sentTo.Load().(string); !strings.HasPrefix(path, "/_matrix/client/v3/rooms/%21abc:example.com/send/") {
+		t.Errorf("message sent to %q, expected the joined room", path)
+	}
+
+	// the failed join on startup was retried once, the successful join is not repeated
+	if err := matrix.Send(context.Background(), Notification{Timestamp: time.Now(), Title: "title", Message: "message"}); err != nil {
+		t.Fatal(err)
+	}
+	if attempts := joinAttempts.Load(); attempts != 2 {
+		t.Errorf("tried to join %d times, want 2 (failed on startup, joined before the first message)", attempts)
+	}
+}
@@ -1,11 +1,44 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 )
 
 // Adapted from https://github.com/mdeheij/mattergo
 
+type mattermostArgs struct {
+	Mattermost        bool   `arg:"env:MATTERMOST" default:"false" help:"Enable/Disable Mattermost Notification (True/False)"`
+	MattermostURL     secret `arg:"env:MATTERMOST_URL" help:"URL of your Mattermost incoming webhook"`
+	MattermostChannel string `arg:"env:MATTERMOST_CHANNEL" help:"Mattermost channel to post in"`
+	MattermostUser    string `arg:"env:MATTERMOST_USER" help:"Mattermost user to post as (default: Docker Event Monitor)"`
+}
+
+var mattermostKind = notifierKind{
+	name: "Mattermost",
+	fromArgs: func() (Notifier, bool) {
+		notifier := &mattermostNotifier{
+			name:    "Mattermost",
+			URL:     glb_arguments.MattermostURL,
+			Channel: glb_arguments.MattermostChannel,
+			User:    glb_arguments.MattermostUser,
+		}
+		return notifier, glb_arguments.Mattermost
+	},
+	new: func(name string) Notifier {
+		return &mattermostNotifier{name: name}
+	},
+}
+
+type mattermostNotifier struct {
+	name string
+	notifierOptions
+	URL     secret `json:"url,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	User    string `json:"user,omitempty"`
+}
+
 // Message is a chat message to be sent using a webhook
 type MattermostMessage struct {
 	Username string `json:"username"`
@@ -13,21 +46,41 @@ type MattermostMessage struct {
 	Text     string `json:"text"`
 }
 
+func (m *mattermostNotifier) Name() string {
+	return m.name
+}
+
+func (m *mattermostNotifier) Validate() error {
+	if len(m.URL) == 0 {
+		return errors.New("Mattermost URL required")
+	}
+	if len(m.User) == 0 {
+		m.User = "Docker Event Monitor"
+	}
+	return nil
+}
+
+func (m *mattermostNotifier) Describe() []setting {
+	return []setting{
+		{key: "MattermostURL", value: m.URL.String()},
+		{key: "MattermostChannel", value: m.Channel},
+		{key: "MattermostUser", value: m.User},
+	}
+}
+
 // Send a message to a Mattermost chat channel
-func sendMattermost(message string, title string) {
+func (m *mattermostNotifier) Send(ctx context.Context, n Notification) error {
 
-	m := MattermostMessage{
-		Username: glb_arguments.MattermostUser,
-		Channel:  glb_arguments.MattermostChannel,
-		Text:     "##### " + title + "\n" + message,
+	message := MattermostMessage{
+		Username: m.User,
+		Channel:  m.Channel,
+		Text:     "##### " + n.Title + "\n" + n.Message,
 	}
 
-	messageJSON, err := json.Marshal(m)
+	messageJSON, err := json.Marshal(message)
 	if err != nil {
-		logger.Error().Err(err).Str("reporter", "Mattermost").Msg("Faild to marshal JSON")
-		return
+		return err
 	}
 
-	sendhttpMessage("Mattermost", glb_arguments.MattermostURL, messageJSON)
-
+	return sendhttpMessage(ctx, m, string(m.URL), messageJSON)
 }
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus metrics, exposed via /metrics if the HTTP listener is enabled
+
+var (
+	eventsReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_event_monitor_events_received_total",
+		Help: "Number of events received from the docker event stream.",
+	}, []string{"type", "action"})
+
+	eventsExcluded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_event_monitor_events_excluded_total",
+		Help: "Number of events excluded from reporting.",
+	}, []string{"type", "action"})
+
+	eventsSuppressed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_event_monitor_events_suppressed_total",
+		Help: "Number of events not reported because of quiet hours.",
+	}, []string{"type", "action"})
+
+	eventsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_event_monitor_events_processed_total",
+		Help: "Number of events processed and reported.",
+	}, []string{"type", "action"})
+
+	lastEventTimestamp = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "docker_event_monitor_last_event_timestamp_seconds",
+		Help: "Timestamp of the last event received from the docker event stream.",
+	})
+
+	streamReconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "docker_event_monitor_stream_reconnects_total",
+		Help: "Number of reconnection attempts to the docker event stream.",
+	})
+
+	notificationsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_event_monitor_notifications_sent_total",
+		Help: "Number of successfully sent notifications, including retries.",
+	}, []string{"reporter", "status_code"})
+
+	notificationsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_event_monitor_notifications_failed_total",
+		Help: "Number of failed attempts to send a notification. The status code is empty for network errors.",
+	}, []string{"reporter", "status_code"})
+
+	notificationsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_event_monitor_notifications_dropped_total",
+		Help: "Number of notifications dropped because the delivery queue was full.",
+	}, []string{"reporter"})
+
+	deliveryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "docker_event_monitor_notification_delivery_duration_seconds",
+		Help:    "Time it took to send a notification.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"reporter"})
+)
+
+// returns the labels for event metrics
+func eventLabels(event events.Message) (string, string) {
+	// strip the dynamic part of actions like "exec_start: sh", otherwise every command would create a new time series
+	action, _, _ := strings.Cut(string(event.Action), ":")
+	return string(event.Type), action
+}
+
+func observeDelivery(reporter string, start time.Time, statusCode int, err error) {
+	// Records the result of a single attempt to send a notification
+
+	deliveryDuration.WithLabelValues(reporter).Observe(time.Since(start).Seconds())
+
+	code := ""
+	if statusCode > 0 {
+		code = strconv.Itoa(statusCode)
+	}
+
+	if err != nil {
+		// SMTP errors carry a reply code as well
+		var smtpErr *textproto.Error
+		if errors.As(err, &smtpErr) {
+			code = strconv.Itoa(smtpErr.Code)
+		}
+		notificationsFailed.WithLabelValues(reporter, code).Inc()
+		return
+	}
+	notificationsSent.WithLabelValues(reporter, code).Inc()
+}
@@ -2,67 +2,224 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
-	"sync"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/docker/docker/api/types/events"
 )
 
-func sendNotifications(timestamp time.Time, message string, title string) {
-	// Sending messages to different services as goroutines concurrently
-	// Adding a wait group here to delay execution until all functions return,
-	// otherwise delaying in processEvent() would not make any sense
+// Notification is a single message handed to all notifiers
+type Notification struct {
+	Timestamp time.Time
+	Title     string
+	Message   string
+	// the reported docker event, nil for messages of the monitor itself
+	Event *events.Message
+	// the event happend while the monitor was not running
+	Replayed bool
+}
 
-	var wg sync.WaitGroup
+// Notifier is implemented by every reporter (Pushover, Gotify, ...)
+type Notifier interface {
+	// Name of the reporter, used for logging and the startup message
+	Name() string
+	// Validate checks the reporter's configuration, called once on startup
+	Validate() error
+	// Send delivers a notification
+	Send(ctx context.Context, n Notification) error
+	// Describe lists the reporter's configuration for the startup message and logging
+	Describe() []setting
+}
 
-	// If there is a server tag, add it to the title
-	if len(glb_arguments.ServerTag) > 0 {
-		title = "[" + glb_arguments.ServerTag + "] " + title
+// notifierOptions are settings shared by all notifiers, embedded in every notifier
+// If not set, the global run-time arguments are used
+type notifierOptions struct {
+	Retries    *int      `json:"retries,omitempty"`
+	RetryDelay *duration `json:"retry_delay,omitempty"`
+	// minimum time between two notifications
+	Spacing       *duration `json:"spacing,omitempty"`
+	QueueSize     int       `json:"queue_size,omitempty"`
+	QueueOverflow string    `json:"queue_overflow,omitempty"`
+	// text/template templates, used instead of the global templates
+	TitleTemplate   string `json:"title_template,omitempty"`
+	MessageTemplate string `json:"message_template,omitempty"`
+	// HMAC-SHA256 signature of HTTP requests, disabled if the secret is empty
+	SigningSecret   secret `json:"signing_secret,omitempty"`
+	SignatureHeader string `json:"signature_header,omitempty"`
+	TimestampHeader string `json:"timestamp_header,omitempty"`
+}
+
+func (o *notifierOptions) options() *notifierOptions {
+	return o
+}
+
+// implemented by all notifiers via the embedded notifierOptions
+type optionsProvider interface {
+	options() *notifierOptions
+}
+
+// setting is a single configuration option of a notifier
+// secrets have to be masked by using their String() method
+type setting struct {
+	key   string
+	value string
+}
+
+// notifierKind describes a type of reporter
+type notifierKind struct {
+	name string
+	// creates the notifier from the supplied run-time arguments, returns false if it is disabled
+	// the notifier is named after its kind
+	fromArgs func() (Notifier, bool)
+	// creates an empty notifier, which is filled from the configuration file
+	new func(name string) Notifier
+}
+
+// registry holds all available kinds of reporters
+// to add a new reporter, embed its arguments in 'args' and add its kind here
+var registry = []notifierKind{
+	pushoverKind,
+	gotifyKind,
+	mailKind,
+	mattermostKind,
+	slackKind,
+	discordKind,
+	telegramKind,
+	matrixKind,
+	teamsKind,
+	ntfyKind,
+	webhookKind,
+}
+
+// enabled notifiers, set up on startup
+var notifiers []Notifier
+
+func setupNotifiers() {
+	// Creates and validates all enabled notifiers
+	// Notifiers declared in the configuration file are set up first. Run-time arguments
+	// override the settings of a declared notifier with the same name as the reporter's kind
+	// (e.g. "pushover"), otherwise they add another notifier
+
+	notifiers = notifiersFromConfig(glb_config.Notifiers)
+
+	for _, kind := range registry {
+		notifier, enabled := kind.fromArgs()
+
+		if configured := findNotifier(notifier.Name()); configured != nil {
+			if reflect.TypeOf(configured) != reflect.TypeOf(notifier) {
+				if enabled {
+					logger.Fatal().Str("reporter", notifier.Name()).Msg("Notifier name already used in configuration file")
+				}
+				continue
+			}
+			if err := overrideNotifier(configured, notifier); err != nil {
+				logger.Fatal().Err(err).Str("reporter", notifier.Name()).Msg("Failed to apply run-time arguments")
+			}
+			continue
+		}
+
+		if enabled {
+			notifiers = append(notifiers, notifier)
+		}
+	}
+
+	for _, notifier := range notifiers {
+		// register the secrets first, validation errors might contain them
+		registerSecrets(notifier)
+		if err := notifier.Validate(); err != nil {
+			logger.Fatal().Err(err).Str("reporter", notifier.Name()).Msg("Invalid notifier configuration")
+		}
 	}
+}
+
+// starter is implemented by notifiers which have to prepare delivery on startup, e.g. join a chat room
+type starter interface {
+	Start(ctx context.Context) error
+}
+
+func startNotifiers() {
+	// Failing to start a notifier is not fatal, the service might just be unavailable right now
 
-	if glb_arguments.Pushover {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			sendPushover(timestamp, message, title)
-		}()
+	for _, notifier := range notifiers {
+		if s, ok := notifier.(starter); ok {
+			if err := s.Start(context.Background()); err != nil {
+				logger.Error().Err(err).Str("reporter", notifier.Name()).Msg("Failed to start notifier")
+			}
+		}
 	}
+}
 
-	if glb_arguments.Gotify {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			sendGotify(message, title)
-		}()
+func findNotifier(name string) Notifier {
+	for _, notifier := range notifiers {
+		if strings.EqualFold(notifier.Name(), name) {
+			return notifier
+		}
 	}
+	return nil
+}
+
+func sendNotifications(n Notification) {
+	// Hands the notification over to the delivery queues of the notifiers
+	// Returns immediately, the notifications are delivered asynchronously
 
-	if glb_arguments.Mail {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			sendMail(timestamp, message, title)
-		}()
+	// If there is a server tag, add it to the title
+	if len(glb_arguments.ServerTag) > 0 {
+		n.Title = "[" + glb_arguments.ServerTag + "] " + n.Title
 	}
 
-	if glb_arguments.Mattermost {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			sendMattermost(message, title)
-		}()
+	for _, notifier := range routeEvent(n.Event) {
+		queues[notifier].enqueue(renderTemplates(notifier, n))
 	}
-	wg.Wait()
+}
 
+func sendhttpMessage(ctx context.Context, notifier Notifier, address string, messageJSON []byte) error {
+	_, err := sendhttpRequest(ctx, notifier, httpRequest{
+		method:  http.MethodPost,
+		address: address,
+		body:    messageJSON,
+	})
+	return err
 }
 
-func sendhttpMessage(reporter string, address string, messageJSON []byte) {
+// httpRequest is a request of a HTTP based notifier
+type httpRequest struct {
+	method  string
+	address string
+	// additional headers, the content type defaults to JSON
+	header http.Header
+	body   []byte
+	// status codes treated as success, defaults to 200
+	successCodes []int
+	// reads the time to wait from the body of rate limited responses, for services
+	// which don't (only) use the 'Retry-After' header. Optional, 0 if not set
+	retryAfter func(body []byte) time.Duration
+}
+
+// sends the request and returns the response body
+func sendhttpRequest(ctx context.Context, notifier Notifier, request httpRequest) ([]byte, error) {
+	reporter := notifier.Name()
 
 	// Create request
-	req, err := http.NewRequest("POST", address, bytes.NewBuffer(messageJSON))
-	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req, err := http.NewRequestWithContext(ctx, request.method, request.address, bytes.NewBuffer(request.body))
 	if err != nil {
-		logger.Error().Err(err).Str("reporter", reporter).Msg("Faild to build request")
-		return
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	for key, values := range request.header {
+		req.Header[key] = values
+	}
+	if provider, ok := notifier.(optionsProvider); ok {
+		signRequest(req, provider.options(), request.body)
 	}
 
 	// define custom httpClient with a default timeout
@@ -71,10 +228,16 @@ func sendhttpMessage(reporter string, address string, messageJSON []byte) {
 	}
 
 	// Send request
+	start := time.Now()
 	resp, err := netClient.Do(req)
 	if err != nil {
-		logger.Error().Err(err).Str("reporter", reporter).Msg("Faild to send request")
-		return
+		observeDelivery(reporter, start, 0, err)
+		// the URL might contain secrets (e.g. Gotify's token), so it's not part of the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -82,22 +245,46 @@ func sendhttpMessage(reporter string, address string, messageJSON []byte) {
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		logger.Error().Err(err).Str("reporter", reporter).Msg("")
-		return
+		observeDelivery(reporter, start, statusCode, err)
+		return nil, err
 	}
 
-	// Log non successfull status codes
-	if statusCode == 200 {
-		logger.Debug().
-			Str("reporter", reporter).
-			Int("statusCode", statusCode).
-			Str("responseBody", string(respBody)).
-			Msg("Message delivered")
-	} else {
-		logger.Error().
-			Str("reporter", reporter).
-			Int("statusCode", statusCode).
-			Str("responseBody", string(respBody)).
-			Msg("Pushing message failed.")
+	successCodes := request.successCodes
+	if len(successCodes) == 0 {
+		successCodes = []int{http.StatusOK}
+	}
+
+	// Report non successfull status codes
+	if !slices.Contains(successCodes, statusCode) {
+		err := &httpError{
+			statusCode: statusCode,
+			body:       string(respBody),
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+		if statusCode == http.StatusTooManyRequests && request.retryAfter != nil {
+			if retryAfter := request.retryAfter(respBody); retryAfter > 0 {
+				err.retryAfter = retryAfter
+			}
+		}
+		observeDelivery(reporter, start, statusCode, err)
+		return nil, err
+	}
+	observeDelivery(reporter, start, statusCode, nil)
+
+	logger.Debug().
+		Str("reporter", reporter).
+		Int("statusCode", statusCode).
+		Str("responseBody", string(respBody)).
+		Msg("Message delivered")
+	return respBody, nil
+}
+
+// notificationID identifies a notification, it stays the same across retries and the outbox
+func notificationID(n Notification) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n%s\n%s", n.Timestamp.UnixNano(), n.Title, n.Message)
+	if n.Event != nil {
+		fmt.Fprintf(hash, "\n%d", n.Event.TimeNano)
 	}
+	return hex.EncodeToString(hash.Sum(nil))[:32]
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryAfterFromBody(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		body       string
+		retryAfter func(body []byte) time.Duration
+		want       time.Duration
+	}{
+		{"discord", "", `{"message":"You are being rate limited.","retry_after":1.5,"global":false}`, discordRetryAfter, 1500 * time.Millisecond},
+		{"telegram", "", `{"ok":false,"error_code":429,"parameters":{"retry_after":7}}`, telegramRetryAfter, 7 * time.Second},
+		{"matrix", "", `{"errcode":"M_LIMIT_EXCEEDED","retry_after_ms":2500}`, matrixRetryAfter, 2500 * time.Millisecond},
+		// the header is used if the body doesn't tell
+		{"matrix without body", "3", `{"errcode":"M_LIMIT_EXCEEDED"}`, matrixRetryAfter, 3 * time.Second},
+		{"invalid body", "3", `<html>`, discordRetryAfter, 3 * time.Second},
+		{"no hook", "3", `{"retry_after":1.5}`, nil, 3 * time.Second},
+	}
+
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(test.header) > 0 {
+				w.Header().Set("Retry-After", test.header)
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(test.body))
+		}))
+
+		_, err := sendhttpRequest(context.Background(), &gotifyNotifier{name: test.name}, httpRequest{
+			method:     http.MethodPost,
+			address:    server.URL,
+			retryAfter: test.retryAfter,
+		})
+		server.Close()
+
+		retryable, retryAfter := isRetryable(err)
+		if !retryable || retryAfter != test.want {
+			t.Errorf("%s: retryable = %v, retry after %s, want %s", test.name, retryable, retryAfter, test.want)
+		}
+	}
+}
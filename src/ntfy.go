@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"text/template"
+)
+
+type ntfyArgs struct {
+	Ntfy         bool   `arg:"env:NTFY" default:"false" help:"Enable/Disable ntfy Notification (True/False)"`
+	NtfyURL      string `arg:"env:NTFY_URL" help:"URL of your ntfy topic, e.g. https://ntfy.sh/mytopic"`
+	NtfyToken    secret `arg:"env:NTFY_TOKEN" help:"ntfy access token"`
+	NtfyUser     string `arg:"env:NTFY_USER" help:"ntfy user for basic authentication"`
+	NtfyPassword secret `arg:"env:NTFY_PASSWORD" help:"ntfy password for basic authentication"`
+	NtfyClick    string `arg:"env:NTFY_CLICK" help:"Go text/template for the URL opened when clicking an event notification"`
+}
+
+var ntfyKind = notifierKind{
+	name: "ntfy",
+	fromArgs: func() (Notifier, bool) {
+		notifier := &ntfyNotifier{
+			name:     "ntfy",
+			URL:      glb_arguments.NtfyURL,
+			Token:    glb_arguments.NtfyToken,
+			User:     glb_arguments.NtfyUser,
+			Password: glb_arguments.NtfyPassword,
+			Click:    glb_arguments.NtfyClick,
+		}
+		return notifier, glb_arguments.Ntfy
+	},
+	new: func(name string) Notifier {
+		return &ntfyNotifier{name: name}
+	},
+}
+
+type ntfyNotifier struct {
+	name string
+	notifierOptions
+	URL      string `json:"url,omitempty"`
+	Token    secret `json:"token,omitempty"`
+	User     string `json:"user,omitempty"`
+	Password secret `json:"password,omitempty"`
+	Click    string `json:"click,omitempty"`
+	// set up by Validate
+	server        string
+	topic         string
+	clickTemplate *template.Template
+}
+
+// NtfyMessage is published as JSON to the ntfy server
+// see https://docs.ntfy.sh/publish/#publish-as-json
+type NtfyMessage struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Click    string   `json:"click,omitempty"`
+}
+
+// ntfyStyle is the priority and the tags of an action
+// see https://docs.ntfy.sh/publish/#message-priority and https://docs.ntfy.sh/emojis/
+type ntfyStyle struct {
+	priority int
+	tags     []string
+}
+
+var ntfyStyles = map[string]ntfyStyle{
+	"die":                      {priority: 5, tags: []string{"rotating_light"}},
+	"oom":                      {priority: 5, tags: []string{"boom"}},
+	"kill":                     {priority: 4, tags: []string{"skull"}},
+	"health_status: unhealthy": {priority: 5, tags: []string{"face_with_thermometer"}},
+	"health_status: healthy":   {priority: 2, tags: []string{"white_check_mark"}},
+	"stop":                     {priority: 3, tags: []string{"stop_sign"}},
+	"restart":                  {priority: 3, tags: []string{"arrows_counterclockwise"}},
+	"pause":                    {priority: 3, tags: []string{"pause_button"}},
+	"destroy":                  {priority: 3, tags: []string{"wastebasket"}},
+	"start":                    {priority: 2, tags: []string{"arrow_forward"}},
+	"unpause":                  {priority: 2, tags: []string{"arrow_forward"}},
+	"create":                   {priority: 2, tags: []string{"new"}},
+}
+
+func (n *ntfyNotifier) Name() string {
+	return n.name
+}
+
+func (n *ntfyNotifier) Validate() error {
+	if len(n.URL) == 0 {
+		return errors.New("ntfy URL required")
+	}
+
+	// messages are published as JSON to the server's root, the topic is part of the message
+	topicURL, err := url.Parse(n.URL)
+	if err != nil || len(topicURL.Host) == 0 {
+		return errors.New("ntfy URL invalid")
+	}
+	n.topic = path.Base(topicURL.Path)
+	if n.topic == "/" || n.topic == "." {
+		return errors.New("ntfy URL has to contain the topic, e.g. https://ntfy.sh/mytopic")
+	}
+	topicURL.Path = path.Dir(topicURL.Path)
+	n.server = strings.TrimRight(topicURL.String(), "/")
+
+	if len(n.Token) > 0 && len(n.User) > 0 {
+		return errors.New("ntfy access token and user can't be used together")
+	}
+
+	if len(n.Click) > 0 {
+		n.clickTemplate, err = template.New(n.name + " click").Funcs(templateFuncs).Option("missingkey=zero").Parse(n.Click)
+		if err != nil {
+			return fmt.Errorf("invalid click template: %w", err)
+		}
+	}
+	return nil
+}
+
+func (n *ntfyNotifier) Describe() []setting {
+	return []setting{
+		{key: "NtfyURL", value: n.URL},
+		{key: "NtfyToken", value: n.Token.String()},
+		{key: "NtfyUser", value: n.User},
+		{key: "NtfyPassword", value: n.Password.String()},
+		{key: "NtfyClick", value: n.Click},
+	}
+}
+
+// Publish a message to a ntfy topic
+func (n *ntfyNotifier) Send(ctx context.Context, notification Notification) error {
+
+	message := NtfyMessage{
+		Topic:   n.topic,
+		Title:   notification.Title,
+		Message: notification.Message,
+	}
+
+	if notification.Event != nil {
+		style := ntfyStyles[string(notification.Event.Action)]
+		if style.priority == 0 {
+			// strip the dynamic part of actions like "exec_start: sh"
+			action, _, _ := strings.Cut(string(notification.Event.Action), ":")
+			style = ntfyStyles[action]
+		}
+		message.Priority = style.priority
+		message.Tags = style.tags
+
+		if n.clickTemplate != nil {
+			message.Click = executeTemplate(n, n.clickTemplate, newTemplateData(notification), "")
+		}
+	}
+
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	if len(n.Token) > 0 {
+		header.Set("Authorization", "Bearer "+string(n.Token))
+	}
+	if len(n.User) > 0 {
+		credentials := base64.StdEncoding.EncodeToString([]byte(n.User + ":" + string(n.Password)))
+		header.Set("Authorization", "Basic "+credentials)
+	}
+
+	_, err = sendhttpRequest(ctx, n, httpRequest{
+		method:  http.MethodPost,
+		address: n.server,
+		header:  header,
+		body:    messageJSON,
+	})
+	return err
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The outbox stores notifications which could not be delivered on disk, one directory per notifier.
+// They are delivered in order, once the notifier is reachable again. As long as a notifier has
+// pending notifications, new ones are added to the outbox as well, so the order is preserved
+
+// serialize access to the outbox, one lock per notifier, so an unreachable notifier
+// doesn't hold up the others while its outbox is flushed
+var (
+	outboxLocksMutex sync.Mutex
+	outboxLocks      = make(map[Notifier]*sync.Mutex)
+)
+
+func outboxLock(notifier Notifier) *sync.Mutex {
+	outboxLocksMutex.Lock()
+	defer outboxLocksMutex.Unlock()
+
+	lock, exists := outboxLocks[notifier]
+	if !exists {
+		lock = &sync.Mutex{}
+		outboxLocks[notifier] = lock
+	}
+	return lock
+}
+
+func outboxEnabled() bool {
+	return len(glb_arguments.OutboxDir) > 0
+}
+
+func outboxDir(notifier Notifier) string {
+	return filepath.Join(glb_arguments.OutboxDir, url.PathEscape(notifier.Name()))
+}
+
+func deliver(notifier Notifier, n Notification) {
+	// Delivers a notification to a single notifier, failed notifications end up in the outbox
+
+	if outboxEnabled() && hasPending(notifier) {
+		logger.Debug().Str("reporter", notifier.Name()).Msg("Notifier has pending notifications, adding to outbox")
+		addToOutbox(notifier, n)
+		return
+	}
+
+	err := sendWithRetry(notifier, n)
+	if err == nil {
+		return
+	}
+	logger.Error().Err(err).Str("reporter", notifier.Name()).Msg("Sending notification failed")
+
+	if retryable, _ := isRetryable(err); retryable && outboxEnabled() {
+		addToOutbox(notifier, n)
+	}
+}
+
+func hasPending(notifier Notifier) bool {
+	lock := outboxLock(notifier)
+	lock.Lock()
+	defer lock.Unlock()
+
+	files, _ := pendingFiles(notifier)
+	return len(files) > 0
+}
+
+// returns the pending notifications of a notifier, oldest first
+func pendingFiles(notifier Notifier) ([]string, error) {
+	entries, err := os.ReadDir(outboxDir(notifier))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && filepath.Ext(entry.Name()) == ".json" {
+			files = append(files, filepath.Join(outboxDir(notifier), entry.Name()))
+		}
+	}
+	// file names start with the time they were added
+	sort.Strings(files)
+	return files, nil
+}
+
+func addToOutbox(notifier Notifier, n Notification) {
+	lock := outboxLock(notifier)
+	lock.Lock()
+	defer lock.Unlock()
+
+	err := os.MkdirAll(outboxDir(notifier), 0o755)
+	if err != nil {
+		logger.Error().Err(err).Str("reporter", notifier.Name()).Msg("Failed to create outbox")
+		return
+	}
+
+	b, err := json.Marshal(n)
+	if err != nil {
+		logger.Error().Err(err).Str("reporter", notifier.Name()).Msg("Failed to marshal JSON")
+		return
+	}
+
+	name := fmt.Sprintf("%020d-%08x.json", time.Now().UnixNano(), rand.Uint32())
+	path := filepath.Join(outboxDir(notifier), name)
+
+	// write to a temporary file first, so a half-written file is never picked up
+	err = os.WriteFile(path+".tmp", b, 0o600)
+	if err == nil {
+		err = os.Rename(path+".tmp", path)
+	}
+	if err != nil {
+		logger.Error().Err(err).Str("reporter", notifier.Name()).Msg("Failed to add notification to outbox")
+		return
+	}
+	logger.Info().Str("reporter", notifier.Name()).Msg("Notification added to outbox")
+}
+
+func processOutbox() {
+	// Periodically tries to deliver all pending notifications, runs as goroutine
+
+	ticker := time.NewTicker(glb_arguments.OutboxInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, notifier := range notifiers {
+			flushOutbox(notifier)
+		}
+		<-ticker.C
+	}
+}
+
+func flushOutbox(notifier Notifier) {
+	lock := outboxLock(notifier)
+	lock.Lock()
+	defer lock.Unlock()
+
+	files, err := pendingFiles(notifier)
+	if err != nil {
+		logger.Error().Err(err).Str("reporter", notifier.Name()).Msg("Failed to read outbox")
+		return
+	}
+
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			logger.Error().Err(err).Str("file", file).Msg("Failed to read notification from outbox")
+			return
+		}
+
+		var n Notification
+		if err := json.Unmarshal(b, &n); err != nil {
+			// a broken file would block the outbox forever
+			logger.Error().Err(err).Str("file", file).Msg("Dropping invalid notification from outbox")
+			os.Remove(file)
+			continue
+		}
+
+		// no retries here, the next attempt happens on the next run anyway
+		err = notifier.Send(context.Background(), n)
+		if err != nil {
+			if retryable, _ := isRetryable(err); retryable {
+				logger.Debug().Err(err).Str("reporter", notifier.Name()).Msg("Notifier still unreachable, keeping outbox")
+				return
+			}
+			logger.Error().Err(err).Str("reporter", notifier.Name()).Msg("Dropping notification from outbox")
+		} else {
+			logger.Info().Str("reporter", notifier.Name()).Msg("Delivered notification from outbox")
+		}
+
+		if err := os.Remove(file); err != nil {
+			logger.Error().Err(err).Str("file", file).Msg("Failed to remove notification from outbox")
+			return
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// blockingNotifier blocks in Send until it is released, like an unreachable server
+type blockingNotifier struct {
+	name    string
+	sending chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingNotifier) Name() string        { return b.name }
+func (b *blockingNotifier) Validate() error     { return nil }
+func (b *blockingNotifier) Describe() []setting { return nil }
+
+func (b *blockingNotifier) Send(ctx context.Context, n Notification) error {
+	close(b.sending)
+	<-b.release
+	return nil
+}
+
+func TestFlushOutboxDoesNotBlockOtherNotifiers(t *testing.T) {
+	glb_arguments = args{OutboxDir: t.TempDir()}
+	defer func() { glb_arguments = args{} }()
+
+	slow := &blockingNotifier{name: "slow", sending: make(chan struct{}), release: make(chan struct{})}
+	fast := &blockingNotifier{name: "fast"}
+
+	addToOutbox(slow, Notification{Title: "pending"})
+	flushed := make(chan struct{})
+	go func() {
+		flushOutbox(slow)
+		close(flushed)
+	}()
+	<-slow.sending
+
+	checked := make(chan bool)
+	go func() { checked <- hasPending(fast) }()
+	select {
+	case pending := <-checked:
+		if pending {
+			t.Error("expected no pending notifications for the other notifier")
+		}
+	case <-time.After(time.Second):
+		t.Error("hasPending of another notifier blocked while the outbox was flushed")
+	}
+
+	close(slow.release)
+	<-flushed
+	if hasPending(slow) {
+		t.Error("expected the outbox to be empty after flushing")
+	}
+}
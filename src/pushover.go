@@ -1,11 +1,40 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"strconv"
-	"time"
 )
 
+type pushoverArgs struct {
+	Pushover         bool   `arg:"env:PUSHOVER" default:"false" help:"Enable/Disable Pushover Notification (True/False)"`
+	PushoverAPIToken secret `arg:"env:PUSHOVER_APITOKEN" help:"Pushover's API Token/Key"`
+	PushoverUserKey  secret `arg:"env:PUSHOVER_USER" help:"Pushover's User Key"`
+}
+
+var pushoverKind = notifierKind{
+	name: "Pushover",
+	fromArgs: func() (Notifier, bool) {
+		notifier := &pushoverNotifier{
+			name:     "Pushover",
+			APIToken: glb_arguments.PushoverAPIToken,
+			UserKey:  glb_arguments.PushoverUserKey,
+		}
+		return notifier, glb_arguments.Pushover
+	},
+	new: func(name string) Notifier {
+		return &pushoverNotifier{name: name}
+	},
+}
+
+type pushoverNotifier struct {
+	name string
+	notifierOptions
+	APIToken secret `json:"api_token,omitempty"`
+	UserKey  secret `json:"user_key,omitempty"`
+}
+
 type PushoverMessage struct {
 	Token     string `json:"token"`
 	User      string `json:"user"`
@@ -14,23 +43,42 @@ type PushoverMessage struct {
 	Timestamp string `json:"timestamp"`
 }
 
-func sendPushover(timestamp time.Time, message string, title string) {
+func (p *pushoverNotifier) Name() string {
+	return p.name
+}
+
+func (p *pushoverNotifier) Validate() error {
+	if len(p.APIToken) == 0 {
+		return errors.New("Pushover API token required")
+	}
+	if len(p.UserKey) == 0 {
+		return errors.New("Pushover user key required")
+	}
+	return nil
+}
+
+func (p *pushoverNotifier) Describe() []setting {
+	return []setting{
+		{key: "PushoverAPIToken", value: p.APIToken.String()},
+		{key: "PushoverUserKey", value: p.UserKey.String()},
+	}
+}
+
+func (p *pushoverNotifier) Send(ctx context.Context, n Notification) error {
 	// Send a message to Pushover
 
 	m := PushoverMessage{
-		Token:     glb_arguments.PushoverAPIToken,
-		User:      glb_arguments.PushoverUserKey,
-		Title:     title,
-		Message:   message,
-		Timestamp: strconv.FormatInt(timestamp.Unix(), 10),
+		Token:     string(p.APIToken),
+		User:      string(p.UserKey),
+		Title:     n.Title,
+		Message:   n.Message,
+		Timestamp: strconv.FormatInt(n.Timestamp.Unix(), 10),
 	}
 
 	messageJSON, err := json.Marshal(m)
 	if err != nil {
-		logger.Error().Err(err).Str("reporter", "Pushover").Msg("Faild to marshal JSON")
-		return
+		return err
 	}
 
-	sendhttpMessage("Pushover", "https://api.pushover.net/1/messages.json", messageJSON)
-
+	return sendhttpMessage(ctx, p, "https://api.pushover.net/1/messages.json", messageJSON)
 }
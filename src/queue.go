@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// overflow policies of the delivery queues
+const (
+	overflowDropOldest = "drop-oldest"
+	overflowDropNewest = "drop-newest"
+	overflowBlock      = "block"
+)
+
+// deliveryQueue holds the pending notifications of a single notifier
+// Every notifier has its own queue and worker, so a slow notifier doesn't delay the others
+// and the event loop never blocks on delivery (unless the 'block' overflow policy is used)
+type deliveryQueue struct {
+	notifier Notifier
+	pending  chan Notification
+	// minimum time between two notifications, keeps them in order on the receiving side
+	spacing  time.Duration
+	overflow string
+}
+
+// delivery queues of all notifiers
+var queues = make(map[Notifier]*deliveryQueue)
+
+func setupQueues() {
+	for _, notifier := range notifiers {
+		spacing, size, overflow := queueOptions(notifier)
+
+		if size < 1 {
+			logger.Fatal().Str("reporter", notifier.Name()).Int("queueSize", size).Msg("Queue size must be at least 1")
+		}
+		if err := validateOverflow(overflow); err != nil {
+			logger.Fatal().Err(err).Str("reporter", notifier.Name()).Msg("Invalid queue overflow policy")
+		}
+
+		queues[notifier] = &deliveryQueue{
+			notifier: notifier,
+			pending:  make(chan Notification, size),
+			spacing:  spacing,
+			overflow: overflow,
+		}
+	}
+}
+
+func startQueues() {
+	for _, queue := range queues {
+		go queue.work()
+	}
+}
+
+func validateOverflow(overflow string) error {
+	switch overflow {
+	case overflowDropOldest, overflowDropNewest, overflowBlock:
+		return nil
+	}
+	return fmt.Errorf("unknown overflow policy \"%s\", use %s, %s or %s", overflow, overflowDropOldest, overflowDropNewest, overflowBlock)
+}
+
+func queueOptions(notifier Notifier) (time.Duration, int, string) {
+	spacing := glb_arguments.Delay
+	size := glb_arguments.QueueSize
+	overflow := glb_arguments.QueueOverflow
+
+	if provider, ok := notifier.(optionsProvider); ok {
+		options := provider.options()
+		if options.Spacing != nil {
+			spacing = time.Duration(*options.Spacing)
+		}
+		if options.QueueSize > 0 {
+			size = options.QueueSize
+		}
+		if len(options.QueueOverflow) > 0 {
+			overflow = options.QueueOverflow
+		}
+	}
+	return spacing, size, overflow
+}
+
+func (q *deliveryQueue) enqueue(n Notification) {
+	if q.overflow == overflowBlock {
+		q.pending <- n
+		return
+	}
+
+	for {
+		select {
+		case q.pending <- n:
+			return
+		default:
+		}
+
+		// queue is full
+		if q.overflow == overflowDropNewest {
+			logger.Warn().Str("reporter", q.notifier.Name()).Str("title", n.Title).Msg("Delivery queue full, dropping notification")
+			notificationsDropped.WithLabelValues(q.notifier.Name()).Inc()
+			return
+		}
+
+		// make room by dropping the oldest notification and try again
+		select {
+		case dropped := <-q.pending:
+			logger.Warn().Str("reporter", q.notifier.Name()).Str("title", dropped.Title).Msg("Delivery queue full, dropping oldest notification")
+			notificationsDropped.WithLabelValues(q.notifier.Name()).Inc()
+		default:
+		}
+	}
+}
+
+func (q *deliveryQueue) work() {
+	// Delivers the notifications one after another, runs as goroutine
+
+	for n := range q.pending {
+		// Sometimes events are pushed through the event channel really quickly, but they arrive on the notification clients in
+		// wrong order (probably due to message delivery time), e.g. Pushover is susceptible for this.
+		// Waiting a configurable time before delivering the next notification solves the issue
+		timer := time.NewTimer(q.spacing)
+
+		deliver(q.notifier, n)
+
+		// if delivering is faster than the spacing, wait until it's over
+		// if delivering takes longer than the spacing, the timer already fired and no delay is added
+		<-timer.C
+	}
+}
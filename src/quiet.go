@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	// the image is built from scratch and has no time zone database
+	_ "time/tzdata"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+// quietWindow is a recurring time range, e.g. nightly backups, in which matching events are
+// not reported, or only sent to the listed notifiers
+type quietWindow struct {
+	Name      string     `json:"name"`
+	Days      stringList `json:"days"`
+	Start     string     `json:"start"`
+	End       string     `json:"end"`
+	Timezone  string     `json:"timezone"`
+	Match     routeMatch `json:"match"`
+	Notifiers []string   `json:"notifiers"`
+	// parsed on startup
+	days     map[time.Weekday]bool
+	start    int // minutes after midnight
+	end      int
+	location *time.Location
+	targets  []Notifier
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func setupQuietHours() {
+	// Parses the quiet windows of the configuration file, fails on invalid settings
+
+	for i := range glb_config.QuietHours {
+		w := &glb_config.QuietHours[i]
+		if len(w.Name) == 0 {
+			w.Name = "window " + strconv.Itoa(i+1)
+		}
+		if err := w.parse(); err != nil {
+			logger.Fatal().Err(err).Str("window", w.Name).Msg("Invalid quiet hours in configuration file")
+		}
+		w.targets = resolveNotifiers(w.Notifiers)
+	}
+}
+
+func (w *quietWindow) parse() error {
+	var err error
+	if w.start, err = parseTimeOfDay(w.Start); err != nil {
+		return fmt.Errorf("invalid start: %w", err)
+	}
+	if w.end, err = parseTimeOfDay(w.End); err != nil {
+		return fmt.Errorf("invalid end: %w", err)
+	}
+
+	// the local time zone of the monitor is used by default
+	w.location = time.Local
+	if len(w.Timezone) > 0 {
+		if w.location, err = time.LoadLocation(w.Timezone); err != nil {
+			return err
+		}
+	}
+
+	// every day by default
+	w.days = make(map[time.Weekday]bool)
+	if len(w.Days) == 0 {
+		for _, day := range weekdays {
+			w.days[day] = true
+		}
+	}
+	for _, days := range w.Days {
+		if err := w.addDays(days); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// adds a single day ("mon") or a range of days ("mon-fri") to the window
+func (w *quietWindow) addDays(text string) error {
+	first, last, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(text)), "-")
+	if !isRange {
+		last = first
+	}
+	from, ok := weekdays[firstChars(first, 3)]
+	if !ok {
+		return fmt.Errorf("unknown day \"%s\"", text)
+	}
+	to, ok := weekdays[firstChars(last, 3)]
+	if !ok {
+		return fmt.Errorf("unknown day \"%s\"", text)
+	}
+	// ranges can wrap around the end of the week, e.g. "sat-sun"
+	for day := from; ; day = (day + 1) % 7 {
+		w.days[day] = true
+		if day == to {
+			return nil
+		}
+	}
+}
+
+func firstChars(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// parses "HH:MM" to minutes after midnight, "24:00" is allowed as end of the day
+func parseTimeOfDay(text string) (int, error) {
+	if text == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", text)
+	if err != nil {
+		return 0, fmt.Errorf("\"%s\" should be of the form HH:MM", text)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// checks if the window is active at the given time
+// Windows ending before they start (e.g. 22:00-06:00) continue on the next day, the days
+// refer to the start of the window. Windows with the same start and end last 24 hours
+func (w quietWindow) active(t time.Time) bool {
+	t = t.In(w.location)
+	minute := t.Hour()*60 + t.Minute()
+
+	if w.start < w.end {
+		return w.days[t.Weekday()] && minute >= w.start && minute < w.end
+	}
+	if w.days[t.Weekday()] && minute >= w.start {
+		return true
+	}
+	yesterday := (t.Weekday() + 6) % 7
+	return w.days[yesterday] && minute < w.end
+}
+
+func (w quietWindow) String() string {
+	days := "every day"
+	if len(w.Days) > 0 {
+		days = strings.Join(w.Days, ",")
+	}
+	description := w.Name + ": " + days + " " + w.Start + "-" + w.End + " " + w.location.String()
+	if len(w.targets) > 0 {
+		description += ", only " + strings.Join(w.Notifiers, ", ")
+	} else {
+		description += ", suppressed"
+	}
+	return description
+}
+
+func quietWindowFor(event events.Message) *quietWindow {
+	// Returns the first window which is active at the time of the event and matches it, nil if none
+	// The time of the event is used, so replayed events are treated like they were back then
+
+	timestamp := time.Unix(event.Time, 0)
+	for i, w := range glb_config.QuietHours {
+		if w.active(timestamp) && w.Match.matches(event) {
+			return &glb_config.QuietHours[i]
+		}
+	}
+	return nil
+}
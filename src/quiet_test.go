@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietWindowActive(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		window quietWindow
+		time   string
+		want   bool
+	}{
+		{quietWindow{Start: "02:45", End: "04:00"}, "2026-10-16 02:45", true},
+		{quietWindow{Start: "02:45", End: "04:00"}, "2026-10-16 04:00", false},
+		{quietWindow{Start: "02:45", End: "04:00"}, "2026-10-16 02:44", false},
+		{quietWindow{Start: "00:00", End: "24:00"}, "2026-10-16 23:59", true},
+		// 2026-10-16 is a friday
+		{quietWindow{Days: stringList{"mon-fri"}, Start: "02:45", End: "04:00"}, "2026-10-16 03:00", true},
+		{quietWindow{Days: stringList{"mon-fri"}, Start: "02:45", End: "04:00"}, "2026-10-17 03:00", false},
+		{quietWindow{Days: stringList{"sat-sun", "Wednesday"}, Start: "00:00", End: "24:00"}, "2026-10-18 12:00", true},
+		{quietWindow{Days: stringList{"sat-sun", "Wednesday"}, Start: "00:00", End: "24:00"}, "2026-10-14 12:00", true},
+		{quietWindow{Days: stringList{"sat-sun", "Wednesday"}, Start: "00:00", End: "24:00"}, "2026-10-19 12:00", false},
+		// windows ending before they start continue on the next day
+		{quietWindow{Days: stringList{"fri"}, Start: "22:00", End: "06:00"}, "2026-10-16 23:00", true},
+		{quietWindow{Days: stringList{"fri"}, Start: "22:00", End: "06:00"}, "2026-10-17 05:59", true},
+		{quietWindow{Days: stringList{"fri"}, Start: "22:00", End: "06:00"}, "2026-10-17 23:00", false},
+		{quietWindow{Days: stringList{"fri"}, Start: "22:00", End: "06:00"}, "2026-10-16 05:00", false},
+		// windows with the same start and end last 24 hours
+		{quietWindow{Days: stringList{"sun"}, Start: "03:00", End: "03:00"}, "2026-10-19 02:59", true},
+		{quietWindow{Days: stringList{"sun"}, Start: "03:00", End: "03:00"}, "2026-10-19 03:00", false},
+	}
+
+	for _, test := range tests {
+		w := test.window
+		w.Timezone = "Europe/Berlin"
+		if err := w.parse(); err != nil {
+			t.Fatalf("%v: %v", test.window, err)
+		}
+		// the event might be reported in another time zone
+		at, err := time.ParseInLocation("2006-01-02 15:04", test.time, berlin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := w.active(at.UTC()); got != test.want {
+			t.Errorf("%s %v-%v active at %s = %v, want %v", w.Days, w.Start, w.End, test.time, got, test.want)
+		}
+	}
+}
+
+func TestQuietWindowInvalid(t *testing.T) {
+	tests := []quietWindow{
+		{Start: "03:60", End: "04:00"},
+		{Start: "03:00", End: "25:00"},
+		{Start: "03:00", End: "04:00", Days: stringList{"monday-someday"}},
+		{Start: "03:00", End: "04:00", Timezone: "Europe/Nowhere"},
+	}
+	for _, w := range tests {
+		if err := w.parse(); err == nil {
+			t.Errorf("%s-%s %s %s: expected an error", w.Start, w.End, w.Days, w.Timezone)
+		}
+	}
+}
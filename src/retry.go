@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// upper limit for the delay between two attempts
+const retryDelayMax = 5 * time.Minute
+
+// httpError is returned if a HTTP based notifier receives a non successfull status code
+type httpError struct {
+	statusCode int
+	body       string
+	// delay requested by the server via the 'Retry-After' header, 0 if not set
+	retryAfter time.Duration
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("pushing message failed with status code %d: %s", e.statusCode, e.body)
+}
+
+func sendWithRetry(notifier Notifier, n Notification) error {
+	// Sends the notification and retries with exponential backoff and jitter if sending failed
+	// with a temporary error
+
+	retries, delay := retryOptions(notifier)
+
+	for attempt := 0; ; attempt++ {
+		err := notifier.Send(context.Background(), n)
+		if err == nil {
+			return nil
+		}
+
+		retryable, retryAfter := isRetryable(err)
+		if !retryable || attempt >= retries {
+			return err
+		}
+
+		wait := backoff(delay, attempt)
+		// the server knows best when to try again, but the queue must not be blocked for too long
+		if retryAfter > 0 {
+			wait = min(retryAfter, retryDelayMax)
+		}
+
+		logger.Warn().Err(err).
+			Str("reporter", notifier.Name()).
+			Int("attempt", attempt+1).
+			Str("delay", wait.String()).
+			Msg("Sending notification failed, retrying")
+		time.Sleep(wait)
+	}
+}
+
+func retryOptions(notifier Notifier) (int, time.Duration) {
+	retries := glb_arguments.Retries
+	delay := glb_arguments.RetryDelay
+
+	if provider, ok := notifier.(optionsProvider); ok {
+		options := provider.options()
+		if options.Retries != nil {
+			retries = *options.Retries
+		}
+		if options.RetryDelay != nil {
+			delay = time.Duration(*options.RetryDelay)
+		}
+	}
+	return retries, delay
+}
+
+func backoff(delay time.Duration, attempt int) time.Duration {
+	// Doubles the delay for each attempt and adds a random jitter of +/- 50%,
+	// so several instances don't retry at the very same time
+	// A delay of zero retries immediately
+
+	if delay <= 0 {
+		return 0
+	}
+	wait := delay << attempt
+	if wait <= 0 || wait > retryDelayMax {
+		wait = retryDelayMax
+	}
+	jitter := time.Duration(rand.Int63n(int64(wait)+1)) - wait/2
+	return wait + jitter
+}
+
+func isRetryable(err error) (bool, time.Duration) {
+	// Checks if an error is only temporary, so sending might succeed later on
+
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		retryable := httpErr.statusCode == http.StatusTooManyRequests || httpErr.statusCode >= 500
+		return retryable, httpErr.retryAfter
+	}
+
+	// SMTP reply codes 4xx are transient errors, 5xx are permanent
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code >= 400 && smtpErr.Code < 500, 0
+	}
+
+	// network errors, including timeouts
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true, 0
+	}
+
+	return false, 0
+}
+
+func parseRetryAfter(header string) time.Duration {
+	// 'Retry-After' is either a number of seconds or a HTTP date
+
+	if len(header) == 0 {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		delay   time.Duration
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{time.Second, 0, 500 * time.Millisecond, 1500 * time.Millisecond},
+		{time.Second, 2, 2 * time.Second, 6 * time.Second},
+		// the delay is capped, also if shifting overflows
+		{time.Second, 20, retryDelayMax / 2, retryDelayMax * 3 / 2},
+		{time.Second, 70, retryDelayMax / 2, retryDelayMax * 3 / 2},
+		// no delay retries immediately
+		{0, 0, 0, 0},
+		{0, 5, 0, 0},
+	}
+
+	for _, test := range tests {
+		if got := backoff(test.delay, test.attempt); got < test.min || got > test.max {
+			t.Errorf("backoff(%s, %d) = %s, want between %s and %s", test.delay, test.attempt, got, test.min, test.max)
+		}
+	}
+}
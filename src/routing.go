@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+// route sends all events matching its criteria to the listed notifiers
+type route struct {
+	Match     routeMatch `json:"match"`
+	Notifiers []string   `json:"notifiers"`
+	targets   []Notifier
+}
+
+// routeMatch holds the criteria of a route
+// All set criteria have to match, a criterion matches if any of its values matches
+type routeMatch struct {
+	Type    stringList            `json:"type"`
+	Action  stringList            `json:"action"`
+	Name    stringList            `json:"name"`
+	Project stringList            `json:"project"`
+	Image   stringList            `json:"image"`
+	Labels  map[string]stringList `json:"labels"`
+}
+
+// stringList can be set as single string or as list of strings in the configuration file
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*l = stringList{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(l))
+}
+
+// notifiers for events not matching any route, nil if events should be sent to all notifiers
+var defaultRoute []Notifier
+
+func setupRoutes() {
+	// Resolves the notifier names of all routes, fails if a notifier does not exist
+
+	for i := range glb_config.Routes {
+		glb_config.Routes[i].targets = resolveNotifiers(glb_config.Routes[i].Notifiers)
+	}
+	if glb_config.DefaultRoute != nil {
+		defaultRoute = resolveNotifiers(glb_config.DefaultRoute)
+		// make sure an empty default route is not mistaken for 'all notifiers'
+		if defaultRoute == nil {
+			defaultRoute = []Notifier{}
+		}
+	}
+}
+
+func resolveNotifiers(names []string) []Notifier {
+	var resolved []Notifier
+	for _, name := range names {
+		notifier := findNotifier(name)
+		if notifier == nil {
+			logger.Fatal().Str("reporter", name).Msg("Route uses unknown notifier")
+		}
+		resolved = append(resolved, notifier)
+	}
+	return resolved
+}
+
+func routeEvent(event *events.Message) []Notifier {
+	// Returns the notifiers an event should be sent to
+	// Messages of the monitor itself (event is nil) are sent to all notifiers
+	// Notifiers set by the container's labels take precedence over the routes
+	// and quiet hours over both
+
+	if event == nil {
+		return notifiers
+	}
+
+	if w := quietWindowFor(*event); w != nil && len(w.targets) > 0 {
+		logger.Debug().
+			Str("ActorID", getActorID(*event)).
+			Strs("notifiers", w.Notifiers).
+			Msgf("Event during quiet hours \"%s\"", w.Name)
+		return w.targets
+	}
+
+	if labelled, ok := labelledNotifiers(*event); ok {
+		return labelled
+	}
+
+	// the first matching route wins
+	for i, r := range glb_config.Routes {
+		if r.Match.matches(*event) {
+			logger.Debug().
+				Str("ActorID", getActorID(*event)).
+				Strs("notifiers", r.Notifiers).
+				Msgf("Event matched route %d", i+1)
+			return r.targets
+		}
+	}
+
+	if defaultRoute != nil {
+		return defaultRoute
+	}
+	return notifiers
+}
+
+func (m routeMatch) matches(event events.Message) bool {
+	if !matchAny(m.Type, string(event.Type)) {
+		return false
+	}
+	if !matchAction(m.Action, string(event.Action)) {
+		return false
+	}
+	if !matchAny(m.Name, event.Actor.Attributes["name"]) {
+		return false
+	}
+	if !matchAny(m.Project, event.Actor.Attributes["com.docker.compose.project"]) {
+		return false
+	}
+	if !matchAny(m.Image, getActorImage(event)) {
+		return false
+	}
+	for label, values := range m.Labels {
+		value, exists := event.Actor.Attributes[label]
+		if !exists || !matchAny(values, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches if no values are set or the value is one of the values
+func matchAny(values stringList, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// like matchAny, but also matches actions with dynamic suffix, e.g. "exec_start: sh" or "health_status: healthy"
+func matchAction(values stringList, action string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == action || strings.HasPrefix(action, v+":") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// mask replaces secrets in logs and messages
+const secretMask = "********"
+
+// secrets shorter than this are not redacted from the log output,
+// otherwise every occurrence of e.g. a single character would be replaced
+const secretMinLength = 4
+
+// secret holds credentials like tokens or passwords
+// It is masked whenever it is logged or rendered, unless --show-secrets is set
+type secret string
+
+func (s secret) String() string {
+	if len(s) == 0 || glb_arguments.ShowSecrets {
+		return string(s)
+	}
+	return secretMask
+}
+
+// values of all configured secrets, redacted from the log output
+var secretValues []string
+
+func registerSecrets(v interface{}) {
+	// Collects the values of all secret fields of a struct (and its embedded structs)
+	collectSecrets(reflect.Indirect(reflect.ValueOf(v)))
+}
+
+func collectSecrets(value reflect.Value) {
+	if value.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		switch {
+		case field.Type() == reflect.TypeOf(secret("")):
+			if field.Len() >= secretMinLength {
+				secretValues = append(secretValues, field.String())
+			}
+		case field.Type() == reflect.TypeOf([]secret{}):
+			for j := 0; j < field.Len(); j++ {
+				if field.Index(j).Len() >= secretMinLength {
+					secretValues = append(secretValues, field.Index(j).String())
+				}
+			}
+		case field.Kind() == reflect.Struct && value.Type().Field(i).Anonymous:
+			collectSecrets(field)
+		}
+	}
+}
+
+// redactingWriter replaces all secret values before the log output is written
+// This catches secrets which end up in logs indirectly, e.g. as part of an URL in an error message
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (r redactingWriter) Write(p []byte) (int, error) {
+	if glb_arguments.ShowSecrets {
+		return r.w.Write(p)
+	}
+
+	redacted := p
+	for _, value := range secretValues {
+		redacted = bytes.ReplaceAll(redacted, []byte(value), []byte(secretMask))
+
+		// the log output is JSON, so the secret might be escaped
+		escaped, err := json.Marshal(value)
+		if err == nil {
+			escaped = escaped[1 : len(escaped)-1]
+			redacted = bytes.ReplaceAll(redacted, escaped, []byte(secretMask))
+		}
+	}
+
+	if _, err := r.w.Write(redacted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func readSecretFile(path string) (string, error) {
+	// Reads a secret from a file, e.g. a Docker secret in /run/secrets/
+	// Trailing whitespace (like a final newline) is removed
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), " \t\r\n"), nil
+}
+
+// reads a list of secrets from a file, one per line, e.g. headers of the webhook
+// Empty lines are skipped
+func readSecretListFile(path string) ([]string, error) {
+	content, err := readSecretFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); len(line) > 0 {
+			values = append(values, line)
+		}
+	}
+	return values, nil
+}
+
+func loadSecretFiles(value reflect.Value) error {
+	// Every secret run-time argument can be read from a file, by setting
+	// the environment variable with the suffix _FILE, e.g. GOTIFY_TOKEN_FILE
+	// Lists of secrets (e.g. WEBHOOK_HEADERS_FILE) contain one value per line
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		structField := value.Type().Field(i)
+
+		if field.Kind() == reflect.Struct && structField.Anonymous {
+			if err := loadSecretFiles(field); err != nil {
+				return err
+			}
+			continue
+		}
+		isList := field.Type() == reflect.TypeOf([]secret{})
+		if field.Type() != reflect.TypeOf(secret("")) && !isList {
+			continue
+		}
+
+		env := envName(structField)
+		if len(env) == 0 {
+			continue
+		}
+		path, exists := os.LookupEnv(env + "_FILE")
+		if !exists {
+			continue
+		}
+		if field.Len() > 0 {
+			return fmt.Errorf("%s and %s_FILE are both set, use only one of them", env, env)
+		}
+		if isList {
+			values, err := readSecretListFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s_FILE: %w", env, err)
+			}
+			list := reflect.MakeSlice(field.Type(), len(values), len(values))
+			for j, v := range values {
+				list.Index(j).SetString(v)
+			}
+			field.Set(list)
+			continue
+		}
+		content, err := readSecretFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s_FILE: %w", env, err)
+		}
+		field.SetString(content)
+	}
+	return nil
+}
+
+// returns the environment variable of a run-time argument, as defined in its 'arg' tag
+func envName(field reflect.StructField) string {
+	for _, option := range strings.Split(field.Tag.Get("arg"), ",") {
+		if strings.HasPrefix(option, "env:") {
+			return strings.TrimPrefix(option, "env:")
+		}
+	}
+	return ""
+}
+
+func loadSecretSettings(settings map[string]interface{}, notifier Notifier) error {
+	// Secrets of notifiers in the configuration file can be read from a file as well,
+	// by adding the suffix _file to the setting, e.g. token_file
+
+	return loadSecretFields(settings, reflect.Indirect(reflect.ValueOf(notifier)))
+}
+
+func loadSecretFields(settings map[string]interface{}, value reflect.Value) error {
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+
+		// shared options like the signing secret are embedded
+		if structField.Anonymous && structField.Type.Kind() == reflect.Struct {
+			if err := loadSecretFields(settings, value.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		isList := structField.Type == reflect.TypeOf([]secret{})
+		if structField.Type != reflect.TypeOf(secret("")) && !isList {
+			continue
+		}
+
+		key, _, _ := strings.Cut(structField.Tag.Get("json"), ",")
+		path, exists := settings[key+"_file"]
+		if !exists {
+			continue
+		}
+		if _, exists := settings[key]; exists {
+			return fmt.Errorf("%s and %s_file are both set, use only one of them", key, key)
+		}
+		pathString, ok := path.(string)
+		if !ok {
+			return fmt.Errorf("%s_file must be a string", key)
+		}
+		if isList {
+			values, err := readSecretListFile(pathString)
+			if err != nil {
+				return fmt.Errorf("failed to read %s_file: %w", key, err)
+			}
+			settings[key] = values
+			delete(settings, key+"_file")
+			continue
+		}
+		content, err := readSecretFile(pathString)
+		if err != nil {
+			return fmt.Errorf("failed to read %s_file: %w", key, err)
+		}
+		settings[key] = content
+		delete(settings, key+"_file")
+	}
+	return nil
+}
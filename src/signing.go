@@ -0,0 +1,45 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// default headers of signed requests
+const (
+	signatureHeader = "X-Signature-256"
+	timestampHeader = "X-Signature-Timestamp"
+)
+
+func signRequest(req *http.Request, options *notifierOptions, body []byte) {
+	// Signs the request body with HMAC-SHA256, so the receiver can verify it was sent by the monitor
+	// The signature covers "<timestamp>.<body>", the receiver should reject requests with an old
+	// timestamp, so a captured request can't be replayed later on
+
+	if len(options.SigningSecret) == 0 {
+		return
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(options.SigningSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	header := options.SignatureHeader
+	if len(header) == 0 {
+		header = signatureHeader
+	}
+	req.Header.Set(header, signature)
+
+	header = options.TimestampHeader
+	if len(header) == 0 {
+		header = timestampHeader
+	}
+	req.Header.Set(header, timestamp)
+}
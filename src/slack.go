@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type slackArgs struct {
+	Slack        bool   `arg:"env:SLACK" default:"false" help:"Enable/Disable Slack Notification (True/False)"`
+	SlackURL     secret `arg:"env:SLACK_URL" help:"URL of your Slack incoming webhook"`
+	SlackChannel string `arg:"env:SLACK_CHANNEL" help:"Slack channel to post in, overrides the webhook's default channel"`
+	SlackUser    string `arg:"env:SLACK_USER" help:"Slack user name to post as, overrides the webhook's default user name"`
+}
+
+var slackKind = notifierKind{
+	name: "Slack",
+	fromArgs: func() (Notifier, bool) {
+		notifier := &slackNotifier{
+			name:    "Slack",
+			URL:     glb_arguments.SlackURL,
+			Channel: glb_arguments.SlackChannel,
+			User:    glb_arguments.SlackUser,
+		}
+		return notifier, glb_arguments.Slack
+	},
+	new: func(name string) Notifier {
+		return &slackNotifier{name: name}
+	},
+}
+
+type slackNotifier struct {
+	name string
+	notifierOptions
+	URL     secret `json:"url,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	User    string `json:"user,omitempty"`
+}
+
+// SlackMessage is a message for a Slack incoming webhook
+// The blocks are wrapped in an attachment, as only attachments can have a colour
+// see https://api.slack.com/messaging/composing/layouts
+type SlackMessage struct {
+	Channel     string            `json:"channel,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	Text        string            `json:"text"`
+	Attachments []SlackAttachment `json:"attachments"`
+}
+
+type SlackAttachment struct {
+	Color  string       `json:"color,omitempty"`
+	Blocks []SlackBlock `json:"blocks"`
+}
+
+type SlackBlock struct {
+	Type     string      `json:"type"`
+	Text     *SlackText  `json:"text,omitempty"`
+	Fields   []SlackText `json:"fields,omitempty"`
+	Elements []SlackText `json:"elements,omitempty"`
+}
+
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func (s *slackNotifier) Name() string {
+	return s.name
+}
+
+func (s *slackNotifier) Validate() error {
+	if len(s.URL) == 0 {
+		return errors.New("Slack URL required")
+	}
+	return nil
+}
+
+func (s *slackNotifier) Describe() []setting {
+	return []setting{
+		{key: "SlackURL", value: s.URL.String()},
+		{key: "SlackChannel", value: s.Channel},
+		{key: "SlackUser", value: s.User},
+	}
+}
+
+// Send a message to a Slack channel
+func (s *slackNotifier) Send(ctx context.Context, n Notification) error {
+
+	blocks := []SlackBlock{
+		{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: "*" + slackEscape(n.Title) + "*"}},
+	}
+
+	color := ""
+	if n.Event != nil {
+		color = slackColor(eventSeverity(*n.Event))
+	}
+
+	// events are shown as fields, unless the message is customized with a template
+	if n.Event != nil && notifierTemplates[s].message == nil {
+		fields := slackFields(n)
+		if len(fields) > 0 {
+			blocks = append(blocks, SlackBlock{Type: "section", Fields: fields})
+		}
+	} else if len(n.Message) > 0 {
+		blocks = append(blocks, SlackBlock{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: slackEscape(n.Message)}})
+	}
+
+	// Slack renders the date in the reader's timezone
+	blocks = append(blocks, SlackBlock{Type: "context", Elements: []SlackText{
+		{Type: "mrkdwn", Text: "<!date^" + strconv.FormatInt(n.Timestamp.Unix(), 10) + "^{date_short_pretty} {time_secs}|" + n.Timestamp.Format(time.RFC1123Z) + ">"},
+	}})
+
+	message := SlackMessage{
+		Channel:  s.Channel,
+		Username: s.User,
+		// used for the push notification
+		Text:        n.Title,
+		Attachments: []SlackAttachment{{Color: color, Blocks: blocks}},
+	}
+
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	return sendhttpMessage(ctx, s, string(s.URL), messageJSON)
+}
+
+func slackFields(n Notification) []SlackText {
+	var fields []SlackText
+
+	add := func(name string, value string) {
+		if len(value) > 0 {
+			fields = append(fields, SlackText{Type: "mrkdwn", Text: "*" + name + "*\n" + slackEscape(value)})
+		}
+	}
+	add("ID", getActorID(*n.Event))
+	add("Image", getActorImage(*n.Event))
+	add("Name", getActorName(*n.Event))
+	add("Docker compose project", n.Event.Actor.Attributes["com.docker.compose.project"])
+
+	return fields
+}
+
+func slackColor(s severity) string {
+	switch s {
+	case severityGood:
+		return "#2eb886"
+	case severityWarning:
+		return "#daa038"
+	case severityCritical:
+		return "#a30200"
+	}
+	return "#808080"
+}
+
+// escapes the control characters of Slack's mrkdwn format
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
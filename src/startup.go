@@ -14,33 +14,34 @@ func buildStartupMessage(timestamp time.Time) string {
 	startup_message_builder.WriteString("Docker event monitor started at " + timestamp.Format(time.RFC1123Z) + "\n")
 	startup_message_builder.WriteString("Docker event monitor version: " + version + "\n")
 
-	if glb_arguments.Pushover {
-		startup_message_builder.WriteString("Pushover notification enabled")
-	} else {
-		startup_message_builder.WriteString("Pushover notification disabled")
+	if len(notifiers) == 0 {
+		startup_message_builder.WriteString("No notification enabled")
+	}
+	for i, notifier := range notifiers {
+		if i > 0 {
+			startup_message_builder.WriteString("\n")
+		}
+		startup_message_builder.WriteString(notifier.Name() + " notification enabled")
+		for _, setting := range notifier.Describe() {
+			if setting.value == "" {
+				continue
+			}
+			startup_message_builder.WriteString("\n" + setting.key + ": " + setting.value)
+		}
 	}
 
-	if glb_arguments.Gotify {
-		startup_message_builder.WriteString("\nGotify notification enabled")
-	} else {
-		startup_message_builder.WriteString("\nGotify notification disabled")
+	if len(glb_config.Routes) > 0 {
+		startup_message_builder.WriteString("\nRoutes: " + strconv.Itoa(len(glb_config.Routes)))
 	}
-	if glb_arguments.Mail {
-		startup_message_builder.WriteString("\nE-Mail notification enabled")
-	} else {
-		startup_message_builder.WriteString("\nE-Mail notification disabled")
+	if glb_config.DefaultRoute != nil {
+		startup_message_builder.WriteString("\nDefault route: " + strings.Join(glb_config.DefaultRoute, ", "))
 	}
 
-	if glb_arguments.Mattermost {
-		startup_message_builder.WriteString("\nMattermost notification enabled")
-		if glb_arguments.MattermostChannel != "" {
-			startup_message_builder.WriteString("\nMattermost channel: " + glb_arguments.MattermostChannel)
-		}
-		if glb_arguments.MattermostUser != "" {
-			startup_message_builder.WriteString("\nMattermost username: " + glb_arguments.MattermostUser)
+	for _, w := range glb_config.QuietHours {
+		startup_message_builder.WriteString("\nQuiet hours " + w.String())
+		if w.active(timestamp) {
+			startup_message_builder.WriteString(" (active)")
 		}
-	} else {
-		startup_message_builder.WriteString("\nMattermost notification disabled")
 	}
 
 	if glb_arguments.Delay > 0 {
@@ -49,6 +50,16 @@ func buildStartupMessage(timestamp time.Time) string {
 		startup_message_builder.WriteString("\nDelay disabled")
 	}
 
+	startup_message_builder.WriteString("\nQueue size: " + strconv.Itoa(glb_arguments.QueueSize) + " (" + glb_arguments.QueueOverflow + ")")
+
+	startup_message_builder.WriteString("\nRetries: " + strconv.Itoa(glb_arguments.Retries))
+
+	if glb_arguments.OutboxDir != "" {
+		startup_message_builder.WriteString("\nOutbox: " + glb_arguments.OutboxDir)
+	} else {
+		startup_message_builder.WriteString("\nOutbox: disabled")
+	}
+
 	startup_message_builder.WriteString("\nLog level: " + glb_arguments.LogLevel)
 
 	if glb_arguments.ServerTag != "" {
@@ -57,6 +68,12 @@ func buildStartupMessage(timestamp time.Time) string {
 		startup_message_builder.WriteString("\nServerTag: none")
 	}
 
+	if glb_arguments.StateFile != "" {
+		startup_message_builder.WriteString("\nStateFile: " + glb_arguments.StateFile)
+	} else {
+		startup_message_builder.WriteString("\nStateFile: none")
+	}
+
 	if len(glb_arguments.FilterStrings) > 0 {
 		startup_message_builder.WriteString("\nFilterStrings: " + strings.Join(glb_arguments.FilterStrings, " "))
 	} else {
@@ -69,43 +86,50 @@ func buildStartupMessage(timestamp time.Time) string {
 		startup_message_builder.WriteString("\nExcludeStrings: none")
 	}
 
+	if len(glb_arguments.IncludeStrings) > 0 {
+		startup_message_builder.WriteString("\nIncludeStrings: " + strings.Join(glb_arguments.IncludeStrings, " "))
+	}
+
+	if glb_arguments.ExcludeExpr != "" {
+		startup_message_builder.WriteString("\nExcludeExpr: " + glb_arguments.ExcludeExpr)
+	}
+
+	if glb_arguments.IncludeExpr != "" {
+		startup_message_builder.WriteString("\nIncludeExpr: " + glb_arguments.IncludeExpr)
+	}
+
+	if glb_arguments.LabelEnable {
+		startup_message_builder.WriteString("\nLabelEnable: only containers labelled " + labelEnable + "=true")
+	}
+
 	return startup_message_builder.String()
 }
 
 func logArguments() {
 	logger.Info().
 		Dict("options", zerolog.Dict().
-			Dict("reporter", zerolog.Dict().
-				Dict("Pushover", zerolog.Dict().
-					Bool("enabled", glb_arguments.Pushover).
-					Str("PushoverAPIToken", glb_arguments.PushoverAPIToken).
-					Str("PushoverUserKey", glb_arguments.PushoverUserKey),
-				).
-				Dict("Gotify", zerolog.Dict().
-					Bool("enabled", glb_arguments.Gotify).
-					Str("GotifyURL", glb_arguments.GotifyURL).
-					Str("GotifyToken", glb_arguments.GotifyToken),
-				).
-				Dict("Mail", zerolog.Dict().
-					Bool("enabled", glb_arguments.Mail).
-					Str("MailFrom", glb_arguments.MailFrom).
-					Str("MailTo", glb_arguments.MailTo).
-					Str("MailHost", glb_arguments.MailHost).
-					Str("MailUser", glb_arguments.MailUser).
-					Int("Port", glb_arguments.MailPort),
-				).
-				Dict("Mattermost", zerolog.Dict().
-					Bool("enabled", glb_arguments.Mattermost).
-					Str("MattermostURL", glb_arguments.MattermostURL).
-					Str("MattermostChannel", glb_arguments.MattermostChannel).
-					Str("MattermostUser", glb_arguments.MattermostUser),
-				),
-			).
+			Dict("reporter", describeNotifiers()).
+			Str("Config", glb_arguments.Config).
 			Str("Delay", glb_arguments.Delay.String()).
+			Int("QueueSize", glb_arguments.QueueSize).
+			Str("QueueOverflow", glb_arguments.QueueOverflow).
+			Int("Retries", glb_arguments.Retries).
+			Str("RetryDelay", glb_arguments.RetryDelay.String()).
+			Str("OutboxDir", glb_arguments.OutboxDir).
+			Str("OutboxInterval", glb_arguments.OutboxInterval.String()).
+			Str("TitleTemplate", glb_arguments.TitleTemplate).
+			Str("MessageTemplate", glb_arguments.MessageTemplate).
+			Str("ListenAddress", glb_arguments.ListenAddress).
+			Str("HealthTimeout", glb_arguments.HealthTimeout.String()).
 			Str("Loglevel", glb_arguments.LogLevel).
 			Str("ServerTag", glb_arguments.ServerTag).
+			Str("StateFile", glb_arguments.StateFile).
 			Str("Filter", strings.Join(glb_arguments.FilterStrings, " ")).
-			Str("Exclude", strings.Join(glb_arguments.ExcludeStrings, " ")),
+			Str("Exclude", strings.Join(glb_arguments.ExcludeStrings, " ")).
+			Str("Include", strings.Join(glb_arguments.IncludeStrings, " ")).
+			Str("ExcludeExpr", glb_arguments.ExcludeExpr).
+			Str("IncludeExpr", glb_arguments.IncludeExpr).
+			Bool("LabelEnable", glb_arguments.LabelEnable),
 		).
 		Dict("version", zerolog.Dict().
 			Str("Version", version).
@@ -125,3 +149,15 @@ func stringToUnix(str string) time.Time {
 	tm := time.Unix(i, 0)
 	return tm
 }
+
+func describeNotifiers() *zerolog.Event {
+	dict := zerolog.Dict()
+	for _, notifier := range notifiers {
+		notifierDict := zerolog.Dict()
+		for _, setting := range notifier.Describe() {
+			notifierDict.Str(setting.key, setting.value)
+		}
+		dict.Dict(notifier.Name(), notifierDict)
+	}
+	return dict
+}
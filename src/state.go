@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+func replayEvents(cli *client.Client, filterArgs filters.Args) {
+	// Reports all events between the last processed event (stored in the state file)
+	// and now. Docker keeps only a limited number of past events, so older events might be lost
+
+	checkpoint, err := loadCheckpoint(glb_arguments.StateFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			logger.Info().
+				Str("stateFile", glb_arguments.StateFile).
+				Msg("No state file found, nothing to replay")
+			return
+		}
+		logger.Error().Err(err).
+			Str("stateFile", glb_arguments.StateFile).
+			Msg("Failed to read state file, not replaying events")
+		return
+	}
+
+	// even if replaying fails, the event stream should resume from the checkpoint
+	lastEventTime = checkpoint
+	// 'Since' is inclusive, the event of the checkpoint was already processed
+	skipUntil = checkpoint
+	until := time.Now().UnixNano()
+
+	logger.Info().
+		Str("since", time.Unix(0, checkpoint).Format(time.RFC1123Z)).
+		Msg("Replaying events since last run")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	options := types.EventsOptions{
+		Filters: filterArgs,
+		Since:   formatTimeNano(checkpoint),
+		Until:   formatTimeNano(until),
+	}
+	event_chan, errs := cli.Events(ctx, options)
+
+	for {
+		select {
+		case err := <-errs:
+			// the stream is closed by docker (io.EOF) after all events until 'Until' are sent
+			if err != nil && !errors.Is(err, io.EOF) {
+				logger.Error().Err(err).Msg("Replaying events failed")
+				return
+			}
+			lastEventTime = max(lastEventTime, until)
+			logger.Info().Msg("Replaying events finished")
+			return
+		case event := <-event_chan:
+			handleEvent(event, true)
+		}
+	}
+}
+
+func loadCheckpoint(path string) (int64, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+}
+
+func saveCheckpoint(path string, timeNano int64) {
+	// Write to a temporary file first and rename it afterwards,
+	// so the state file is never left half-written
+	tmp := path + ".tmp"
+
+	err := os.WriteFile(tmp, []byte(strconv.FormatInt(timeNano, 10)+"\n"), 0o644)
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		logger.Error().Err(err).
+			Str("stateFile", path).
+			Msg("Failed to write state file")
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+)
+
+// limits for the exponential backoff between reconnection attempts
+const (
+	reconnectDelayMin = time.Second
+	reconnectDelayMax = time.Minute
+	// the connection counts as restored once the stream delivered an event or stayed up that long
+	streamStableAfter = 30 * time.Second
+)
+
+// TimeNano of the last event received from the event stream
+// used to resume the stream after a reconnect without losing or duplicating events
+var lastEventTime int64
+
+// time of the first subscription to the event stream, in nanoseconds
+// used to resume the stream if it fails before the first event was received
+var subscribedAt int64
+
+// TimeNano up to which events received after (re)subscribing were already processed
+var skipUntil int64
+
+func watchEvents(cli *client.Client, filterArgs filters.Args) {
+	// Supervises the docker event stream. Whenever the stream fails, the monitor reconnects
+	// with an exponential backoff and resumes from the last seen event
+
+	delay := reconnectDelayMin
+	var lostAt time.Time
+
+	for {
+		err := ping(cli)
+		if err == nil {
+			// a successful ping doesn't mean the subscription works, e.g. with invalid filters
+			err = streamEvents(cli, filterArgs, func() {
+				if !lostAt.IsZero() {
+					restoredAt := time.Now()
+					logger.Info().
+						Str("downtime", restoredAt.Sub(lostAt).Round(time.Second).String()).
+						Msg("Connection to docker event stream restored")
+					sendNotifications(Notification{
+						Timestamp: restoredAt,
+						Title:     "Docker event stream connection restored",
+						Message:   buildRestoredMessage(lostAt, restoredAt),
+					})
+					lostAt = time.Time{}
+				}
+				delay = reconnectDelayMin
+			})
+
+			// reconnecting doesn't help if docker rejects the subscription itself
+			if isClientError(err) {
+				logger.Fatal().Err(err).Msg("Docker rejected the subscription to the event stream")
+			}
+		}
+
+		if lostAt.IsZero() {
+			lostAt = time.Now()
+			logger.Error().Err(err).Msg("Connection to docker event stream lost")
+			sendNotifications(Notification{
+				Timestamp: lostAt,
+				Title:     "Docker event stream connection lost",
+				Message:   buildLostMessage(lostAt, err),
+			})
+		}
+
+		logger.Info().
+			Str("delay", delay.String()).
+			Msg("Reconnecting to docker event stream")
+		time.Sleep(delay)
+		streamReconnects.Inc()
+
+		// double the delay for the next attempt, but don't exceed the maximum
+		delay = min(delay*2, reconnectDelayMax)
+	}
+}
+
+func ping(cli *client.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := cli.Ping(ctx)
+	return err
+}
+
+func streamEvents(cli *client.Client, filterArgs filters.Args, established func()) error {
+	// Subscribes to the event stream and processes events until the stream fails
+	// established is called once the stream delivered an event or stayed up for streamStableAfter
+	// Only returns in case of an error
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	options := types.EventsOptions{Filters: filterArgs}
+	options.Since = formatTimeNano(resumePoint())
+
+	// 'Since' is inclusive, so events already processed before the reconnect are sent again
+	skipUntil = lastEventTime
+
+	// receives events from the channel
+	event_chan, errs := cli.Events(ctx, options)
+
+	streamConnected.Store(true)
+	defer streamConnected.Store(false)
+
+	established = sync.OnceFunc(established)
+	stable := time.NewTimer(streamStableAfter)
+	defer stable.Stop()
+
+	for {
+		select {
+		case err := <-errs:
+			if err == nil {
+				err = errors.New("event stream closed")
+			}
+			return err
+		case <-stable.C:
+			established()
+		case event := <-event_chan:
+			established()
+			handleEvent(event, false)
+		}
+	}
+}
+
+// checks if docker rejected the request (4xx), e.g. because of invalid filters
+func isClientError(err error) bool {
+	return errdefs.IsInvalidParameter(err) || errdefs.IsNotFound(err) || errdefs.IsConflict(err) ||
+		errdefs.IsUnauthorized(err) || errdefs.IsForbidden(err)
+}
+
+// returns the time to resume the event stream from: the last event received or,
+// if there was none yet, the first subscription, so no events are lost if the stream fails early
+func resumePoint() int64 {
+	if subscribedAt == 0 {
+		subscribedAt = time.Now().UnixNano()
+	}
+	if lastEventTime > 0 {
+		return lastEventTime
+	}
+	return subscribedAt
+}
+
+func handleEvent(event events.Message, replayed bool) {
+	// mark the event loop as busy, so the health check can detect if it's stuck
+	busySince.Store(time.Now().UnixNano())
+	defer busySince.Store(0)
+
+	// if logging level is debug, log the event
+	logger.Debug().
+		Interface("event", event).Msg("")
+
+	if alreadyProcessed(event) {
+		logger.Debug().Msg("Skipping already processed event")
+		return
+	}
+	lastEventTime = max(lastEventTime, event.TimeNano)
+	if len(glb_arguments.StateFile) > 0 {
+		saveCheckpoint(glb_arguments.StateFile, lastEventTime)
+	}
+
+	eventType, eventAction := eventLabels(event)
+	eventsReceived.WithLabelValues(eventType, eventAction).Inc()
+	lastEventTimestamp.Set(float64(event.TimeNano) / float64(time.Second))
+
+	// Check if event should be reported at all
+	if len(glb_arguments.Include) > 0 || includeExpression != nil {
+		logger.Debug().Msg("Performing check for event inclusion")
+		if !includeEvent(event) {
+			eventsExcluded.WithLabelValues(eventType, eventAction).Inc()
+			return
+		}
+	}
+
+	// Check if the container opted out via its labels
+	if excludedByLabels(event) {
+		eventsExcluded.WithLabelValues(eventType, eventAction).Inc()
+		return
+	}
+
+	// Check if event should be exlcuded from reporting
+	if len(glb_arguments.Exclude) > 0 || excludeExpression != nil {
+		logger.Debug().Msg("Performing check for event exclusion")
+		if excludeEvent(event) {
+			eventsExcluded.WithLabelValues(eventType, eventAction).Inc()
+			return
+		}
+	}
+
+	// Check if the event happened during quiet hours which suppress it
+	if w := quietWindowFor(event); w != nil && len(w.targets) == 0 {
+		logger.Debug().
+			Str("ActorID", getActorID(event)).
+			Msgf("Event suppressed during quiet hours \"%s\"", w.Name)
+		eventsSuppressed.WithLabelValues(eventType, eventAction).Inc()
+		return
+	}
+	processEvent(event, replayed)
+	eventsProcessed.WithLabelValues(eventType, eventAction).Inc()
+}
+
+// checks if the event was sent again after resuming the stream
+// Only the events up to the resume point are skipped, and only until the first newer event
+// arrives. Later events are processed even if they are older than the last one
+func alreadyProcessed(event events.Message) bool {
+	if event.TimeNano <= skipUntil {
+		return true
+	}
+	skipUntil = 0
+	return false
+}
+
+// docker expects timestamps in the form of seconds.nanoseconds
+func formatTimeNano(timeNano int64) string {
+	return fmt.Sprintf("%d.%09d", timeNano/int64(time.Second), timeNano%int64(time.Second))
+}
+
+func buildLostMessage(lostAt time.Time, err error) string {
+	message := "Connection lost at " + lostAt.Format(time.RFC1123Z)
+	if err != nil {
+		message += "\nError: " + err.Error()
+	}
+	return message + "\nReconnecting..."
+}
+
+func buildRestoredMessage(lostAt time.Time, restoredAt time.Time) string {
+	return "Connection lost at " + lostAt.Format(time.RFC1123Z) + "\n" +
+		"Connection restored at " + restoredAt.Format(time.RFC1123Z) + "\n" +
+		"Downtime: " + restoredAt.Sub(lostAt).Round(time.Second).String() + "\n" +
+		"Events missed in the meantime will be reported if still available"
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/errdefs"
+)
+
+func TestResumePoint(t *testing.T) {
+	defer func() {
+		subscribedAt = 0
+		lastEventTime = 0
+	}()
+	subscribedAt = 0
+	lastEventTime = 0
+
+	// the stream fails before the first event: resume from the first subscription
+	before := time.Now().UnixNano()
+	first := resumePoint()
+	if first < before || first > time.Now().UnixNano() {
+		t.Fatalf("resumePoint() = %d, expected the time of the first subscription", first)
+	}
+	if again := resumePoint(); again != first {
+		t.Errorf("resumePoint() = %d after a reconnect, want %d", again, first)
+	}
+
+	// once an event was received, resume from it
+	lastEventTime = first + 42
+	if got := resumePoint(); got != lastEventTime {
+		t.Errorf("resumePoint() = %d, want %d", got, lastEventTime)
+	}
+}
+
+func TestFormatTimeNano(t *testing.T) {
+	if got := formatTimeNano(1708201602005856956); got != "1708201602.005856956" {
+		t.Errorf("formatTimeNano() = %s", got)
+	}
+}
+
+func TestAlreadyProcessed(t *testing.T) {
+	defer func() { skipUntil = 0 }()
+
+	// resumed after the event at 100: docker sends the events since then again
+	skipUntil = 100
+	tests := []struct {
+		timeNano int64
+		want     bool
+	}{
+		{90, true},
+		{100, true},
+		{110, false},
+		// once a newer event arrived, out-of-order events are live ones
+		{105, false},
+		{100, false},
+	}
+
+	for _, test := range tests {
+		if got := alreadyProcessed(events.Message{TimeNano: test.timeNano}); got != test.want {
+			t.Errorf("alreadyProcessed(%d) = %v, want %v", test.timeNano, got, test.want)
+		}
+	}
+}
+
+func TestIsClientError(t *testing.T) {
+	err := errors.New("invalid filter 'foo'")
+	if !isClientError(errdefs.InvalidParameter(err)) {
+		t.Error("expected a 400 response to be a client error")
+	}
+	if isClientError(errdefs.System(err)) || isClientError(err) {
+		t.Error("expected server and connection errors not to be client errors")
+	}
+}
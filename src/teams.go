@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type teamsArgs struct {
+	Teams    bool   `arg:"env:TEAMS" default:"false" help:"Enable/Disable Microsoft Teams Notification (True/False)"`
+	TeamsURL secret `arg:"env:TEAMS_URL" help:"URL of your Teams Workflows (or incoming) webhook"`
+}
+
+var teamsKind = notifierKind{
+	name: "Teams",
+	fromArgs: func() (Notifier, bool) {
+		notifier := &teamsNotifier{
+			name: "Teams",
+			URL:  glb_arguments.TeamsURL,
+		}
+		return notifier, glb_arguments.Teams
+	},
+	new: func(name string) Notifier {
+		return &teamsNotifier{name: name}
+	},
+}
+
+type teamsNotifier struct {
+	name string
+	notifierOptions
+	URL secret `json:"url,omitempty"`
+}
+
+// TeamsMessage is a message with an Adaptive Card for a Teams webhook
+// see https://learn.microsoft.com/en-us/connectors/teams/?tabs=text1#microsoft-teams-webhook
+type TeamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []TeamsAttachment `json:"attachments"`
+}
+
+type TeamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     TeamsCard `json:"content"`
+}
+
+// TeamsCard is an Adaptive Card, see https://adaptivecards.io/explorer/
+type TeamsCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []TeamsElement `json:"body"`
+	MSTeams map[string]any `json:"msteams,omitempty"`
+}
+
+// TeamsElement is an element of an Adaptive Card, only the used ones are supported
+type TeamsElement struct {
+	Type string `json:"type"`
+	// TextBlock
+	Text   string `json:"text,omitempty"`
+	Weight string `json:"weight,omitempty"`
+	Size   string `json:"size,omitempty"`
+	Wrap   bool   `json:"wrap,omitempty"`
+	// Container
+	Style string         `json:"style,omitempty"`
+	Bleed bool           `json:"bleed,omitempty"`
+	Items []TeamsElement `json:"items,omitempty"`
+	// FactSet
+	Facts []TeamsFact `json:"facts,omitempty"`
+}
+
+type TeamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+func (t *teamsNotifier) Name() string {
+	return t.name
+}
+
+func (t *teamsNotifier) Validate() error {
+	if len(t.URL) == 0 {
+		return errors.New("Teams URL required")
+	}
+	return nil
+}
+
+func (t *teamsNotifier) Describe() []setting {
+	return []setting{
+		{key: "TeamsURL", value: t.URL.String()},
+	}
+}
+
+// Send a message to a Teams channel
+func (t *teamsNotifier) Send(ctx context.Context, n Notification) error {
+
+	// coloured header with the title
+	header := TeamsElement{
+		Type:  "Container",
+		Style: "emphasis",
+		Bleed: true,
+		Items: []TeamsElement{
+			{Type: "TextBlock", Text: n.Title, Weight: "Bolder", Size: "Medium", Wrap: true},
+		},
+	}
+	if n.Event != nil {
+		header.Style = teamsStyle(eventSeverity(*n.Event))
+	}
+	body := []TeamsElement{header}
+
+	// events are shown as facts, unless the message is customized with a template
+	if n.Event != nil && notifierTemplates[t].message == nil {
+		facts := TeamsElement{Type: "FactSet"}
+		for _, field := range eventFields(*n.Event) {
+			facts.Facts = append(facts.Facts, TeamsFact{Title: field.name, Value: field.value})
+		}
+		body = append(body, facts)
+	} else if len(n.Message) > 0 {
+		// TextBlocks need an empty line for a line break
+		body = append(body, TeamsElement{Type: "TextBlock", Text: strings.ReplaceAll(n.Message, "\n", "\n\n"), Wrap: true})
+	}
+
+	message := TeamsMessage{
+		Type: "message",
+		Attachments: []TeamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: TeamsCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body:    body,
+				MSTeams: map[string]any{"width": "Full"},
+			},
+		}},
+	}
+
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	// Workflows webhooks answer with 202 Accepted, the retired incoming webhooks with 200
+	_, err = sendhttpRequest(ctx, t, httpRequest{
+		method:       http.MethodPost,
+		address:      string(t.URL),
+		body:         messageJSON,
+		successCodes: []int{http.StatusOK, http.StatusAccepted},
+	})
+	return err
+}
+
+func teamsStyle(s severity) string {
+	switch s {
+	case severityGood:
+		return "good"
+	case severityWarning:
+		return "warning"
+	case severityCritical:
+		return "attention"
+	}
+	return "emphasis"
+}
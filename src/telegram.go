@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type telegramArgs struct {
+	Telegram          bool     `arg:"env:TELEGRAM" default:"false" help:"Enable/Disable Telegram Notification (True/False)"`
+	TelegramToken     secret   `arg:"env:TELEGRAM_TOKEN" help:"Token of your Telegram bot"`
+	TelegramChatIDs   []string `arg:"env:TELEGRAM_CHAT_IDS,--telegramchatid" help:"Telegram chat IDs to send messages to"`
+	TelegramParseMode string   `arg:"env:TELEGRAM_PARSE_MODE" help:"Formatting of Telegram messages: HTML or MarkdownV2 (default: HTML)"`
+	TelegramSilent    bool     `arg:"env:TELEGRAM_SILENT" help:"Send notifications of low-severity actions (e.g. create, start) without sound"`
+	TelegramAPIURL    string   `arg:"env:TELEGRAM_API_URL" help:"Base URL of the Telegram Bot API (default: https://api.telegram.org)"`
+}
+
+var telegramKind = notifierKind{
+	name: "Telegram",
+	fromArgs: func() (Notifier, bool) {
+		notifier := &telegramNotifier{
+			name:      "Telegram",
+			Token:     glb_arguments.TelegramToken,
+			ChatIDs:   glb_arguments.TelegramChatIDs,
+			ParseMode: glb_arguments.TelegramParseMode,
+			Silent:    glb_arguments.TelegramSilent,
+			APIURL:    glb_arguments.TelegramAPIURL,
+		}
+		return notifier, glb_arguments.Telegram
+	},
+	new: func(name string) Notifier {
+		return &telegramNotifier{name: name}
+	},
+}
+
+type telegramNotifier struct {
+	name string
+	notifierOptions
+	Token     secret   `json:"token,omitempty"`
+	ChatIDs   []string `json:"chat_ids,omitempty"`
+	ParseMode string   `json:"parse_mode,omitempty"`
+	Silent    bool     `json:"silent,omitempty"`
+	APIURL    string   `json:"api_url,omitempty"`
+	// chats which already received a notification, by notification ID
+	// Retries (including the ones from the outbox) are only sent to the chats which failed
+	delivered      map[string]telegramDelivery
+	deliveredMutex sync.Mutex
+}
+
+type telegramDelivery struct {
+	chats map[string]bool
+	since time.Time
+}
+
+// deliveries older than this are forgotten, so failed notifications don't pile up
+const telegramDeliveryExpiry = 24 * time.Hour
+
+const (
+	telegramParseModeHTML     = "HTML"
+	telegramParseModeMarkdown = "MarkdownV2"
+)
+
+// TelegramMessage is the request of the Bot API's sendMessage method
+// see https://core.telegram.org/bots/api#sendmessage
+type TelegramMessage struct {
+	ChatID              string `json:"chat_id"`
+	Text                string `json:"text"`
+	ParseMode           string `json:"parse_mode"`
+	DisableNotification bool   `json:"disable_notification,omitempty"`
+}
+
+// body of the Bot API's error responses
+type telegramError struct {
+	Parameters struct {
+		// seconds to wait before trying again, set if rate limited
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+func (t *telegramNotifier) Name() string {
+	return t.name
+}
+
+func (t *telegramNotifier) Validate() error {
+	if len(t.Token) == 0 {
+		return errors.New("Telegram bot token required")
+	}
+	if len(t.ChatIDs) == 0 {
+		return errors.New("Telegram chat ID required")
+	}
+	switch {
+	case len(t.ParseMode) == 0, strings.EqualFold(t.ParseMode, telegramParseModeHTML):
+		t.ParseMode = telegramParseModeHTML
+	case strings.EqualFold(t.ParseMode, telegramParseModeMarkdown):
+		t.ParseMode = telegramParseModeMarkdown
+	default:
+		return fmt.Errorf("unknown Telegram parse mode \"%s\", use %s or %s", t.ParseMode, telegramParseModeHTML, telegramParseModeMarkdown)
+	}
+	if len(t.APIURL) == 0 {
+		t.APIURL = "https://api.telegram.org"
+	}
+	t.APIURL = strings.TrimRight(t.APIURL, "/")
+	return nil
+}
+
+func (t *telegramNotifier) Describe() []setting {
+	return []setting{
+		{key: "TelegramToken", value: t.Token.String()},
+		{key: "TelegramChatIDs", value: strings.Join(t.ChatIDs, ",")},
+		{key: "TelegramParseMode", value: t.ParseMode},
+		{key: "TelegramSilent", value: fmt.Sprint(t.Silent)},
+		{key: "TelegramAPIURL", value: t.APIURL},
+	}
+}
+
+// Send a message to all configured Telegram chats
+func (t *telegramNotifier) Send(ctx context.Context, n Notification) error {
+
+	text := t.format(n)
+
+	// notifications of low-severity actions are delivered without sound
+	silent := t.Silent && n.Event != nil && eventSeverity(*n.Event) < severityWarning
+
+	address := t.APIURL + "/bot" + string(t.Token) + "/sendMessage"
+
+	id := notificationID(n)
+
+	// a failed chat doesn't stop delivery to the others
+	var errs []error
+	for _, chatID := range t.ChatIDs {
+		if t.wasDelivered(id, chatID) {
+			continue
+		}
+
+		message := TelegramMessage{
+			ChatID:              chatID,
+			Text:                text,
+			ParseMode:           t.ParseMode,
+			DisableNotification: silent,
+		}
+
+		messageJSON, err := json.Marshal(message)
+		if err != nil {
+			return err
+		}
+
+		_, err = sendhttpRequest(ctx, t, httpRequest{
+			method:     http.MethodPost,
+			address:    address,
+			body:       messageJSON,
+			retryAfter: telegramRetryAfter,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("chat %s: %w", chatID, err))
+			continue
+		}
+		t.markDelivered(id, chatID)
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	t.forgetDelivered(id)
+	return nil
+}
+
+// the Bot API tells the time to wait in the body of rate limited responses
+func telegramRetryAfter(body []byte) time.Duration {
+	var apiErr telegramError
+	if json.Unmarshal(body, &apiErr) != nil {
+		return 0
+	}
+	return time.Duration(apiErr.Parameters.RetryAfter) * time.Second
+}
+
+func (t *telegramNotifier) wasDelivered(id string, chatID string) bool {
+	t.deliveredMutex.Lock()
+	defer t.deliveredMutex.Unlock()
+
+	return t.delivered[id].chats[chatID]
+}
+
+func (t *telegramNotifier) markDelivered(id string, chatID string) {
+	t.deliveredMutex.Lock()
+	defer t.deliveredMutex.Unlock()
+
+	if t.delivered == nil {
+		t.delivered = make(map[string]telegramDelivery)
+	}
+	for key, delivery := range t.delivered {
+		if time.Since(delivery.since) > telegramDeliveryExpiry {
+			delete(t.delivered, key)
+		}
+	}
+
+	delivery, exists := t.delivered[id]
+	if !exists {
+		delivery = telegramDelivery{chats: make(map[string]bool), since: time.Now()}
+		t.delivered[id] = delivery
+	}
+	delivery.chats[chatID] = true
+}
+
+func (t *telegramNotifier) forgetDelivered(id string) {
+	t.deliveredMutex.Lock()
+	defer t.deliveredMutex.Unlock()
+
+	delete(t.delivered, id)
+}
+
+// formats title and message according to the parse mode, the title is shown in bold
+func (t *telegramNotifier) format(n Notification) string {
+	if t.ParseMode == telegramParseModeMarkdown {
+		return "*" + escapeMarkdownV2(n.Title) + "*\n" + escapeMarkdownV2(n.Message)
+	}
+	return "<b>" + html.EscapeString(n.Title) + "</b>\n" + html.EscapeString(n.Message)
+}
+
+// escapes all characters with a special meaning in Telegram's MarkdownV2
+// see https://core.telegram.org/bots/api#markdownv2-style
+func escapeMarkdownV2(text string) string {
+	var builder strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune("\\_*[]()~`>#+-=|{}.!", r) {
+			builder.WriteRune('\\')
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTelegramRetriesOnlyFailedChats(t *testing.T) {
+	var mutex sync.Mutex
+	received := make(map[string]int)
+	failing := true
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message TelegramMessage
+		json.NewDecoder(r.Body).Decode(&message)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if message.ChatID == "2" && failing {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		received[message.ChatID]++
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	telegram := &telegramNotifier{name: "Telegram", Token: "token", ChatIDs: []string{"1", "2", "3"}, APIURL: server.URL}
+	if err := telegram.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	n := Notification{Timestamp: time.Now(), Title: "Container web: die", Message: "ID: 01234567"}
+
+	if err := telegram.Send(context.Background(), n); err == nil {
+		t.Fatal("expected an error for chat 2")
+	}
+	mutex.Lock()
+	failing = false
+	mutex.Unlock()
+	if err := telegram.Send(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"1": 1, "2": 1, "3": 1}
+	if !reflect.DeepEqual(received, want) {
+		t.Errorf("messages per chat = %v, want %v", received, want)
+	}
+	if len(telegram.delivered) != 0 {
+		t.Errorf("expected the delivery to be forgotten once all chats received it")
+	}
+
+	// another notification is sent to all chats again
+	n.Title = "Container web: start"
+	if err := telegram.Send(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]int{"1": 2, "2": 2, "3": 2}
+	if !reflect.DeepEqual(received, want) {
+		t.Errorf("messages per chat = %v, want %v", received, want)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+// templateData is available in title and message templates
+type templateData struct {
+	Event             events.Message
+	ActorID           string
+	ActorName         string
+	ActorImage        string
+	ActorImageVersion string
+	ServerTag         string
+	Replayed          bool
+	// the default title and message
+	Title   string
+	Message string
+}
+
+// messageTemplates are the title and message templates of a notifier, nil if not set
+type messageTemplates struct {
+	title   *template.Template
+	message *template.Template
+}
+
+// templates of all notifiers
+var notifierTemplates = make(map[Notifier]messageTemplates)
+
+var templateFuncs = template.FuncMap{
+	"shortID":  shortID,
+	"humanize": humanize,
+	"label":    label,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"json":     toJSON,
+}
+
+func setupTemplates() {
+	// Parses all templates on startup, so syntax errors are reported right away
+	// Notifiers without own templates use the global ones
+
+	global := messageTemplates{
+		title:   mustParseTemplate("title", glb_arguments.TitleTemplate),
+		message: mustParseTemplate("message", glb_arguments.MessageTemplate),
+	}
+
+	for _, notifier := range notifiers {
+		templates := global
+		if provider, ok := notifier.(optionsProvider); ok {
+			options := provider.options()
+			if len(options.TitleTemplate) > 0 {
+				templates.title = mustParseTemplate(notifier.Name()+" title", options.TitleTemplate)
+			}
+			if len(options.MessageTemplate) > 0 {
+				templates.message = mustParseTemplate(notifier.Name()+" message", options.MessageTemplate)
+			}
+		}
+		notifierTemplates[notifier] = templates
+	}
+}
+
+func mustParseTemplate(name string, text string) *template.Template {
+	if len(text) == 0 {
+		return nil
+	}
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		logger.Fatal().Err(err).Str("template", name).Msg("Failed to parse template")
+	}
+	return tmpl
+}
+
+func renderTemplates(notifier Notifier, n Notification) Notification {
+	// Replaces title and message of an event notification with the rendered templates of the notifier
+	// Messages of the monitor itself are not templated
+
+	templates := notifierTemplates[notifier]
+	if n.Event == nil || (templates.title == nil && templates.message == nil) {
+		return n
+	}
+
+	data := newTemplateData(n)
+	if templates.title != nil {
+		n.Title = executeTemplate(notifier, templates.title, data, n.Title)
+	}
+	if templates.message != nil {
+		n.Message = executeTemplate(notifier, templates.message, data, n.Message)
+	}
+	return n
+}
+
+func newTemplateData(n Notification) templateData {
+	return templateData{
+		Event:             *n.Event,
+		ActorID:           getActorID(*n.Event),
+		ActorName:         getActorName(*n.Event),
+		ActorImage:        getActorImage(*n.Event),
+		ActorImageVersion: getActorImageVersion(*n.Event),
+		ServerTag:         glb_arguments.ServerTag,
+		Replayed:          n.Replayed,
+		Title:             n.Title,
+		Message:           n.Message,
+	}
+}
+
+// executes a template, falls back to the default text if it fails
+func executeTemplate(notifier Notifier, tmpl *template.Template, data templateData, fallback string) string {
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, data); err != nil {
+		logger.Error().Err(err).
+			Str("reporter", notifier.Name()).
+			Str("template", tmpl.Name()).
+			Msg("Failed to execute template, using default")
+		return fallback
+	}
+	return builder.String()
+}
+
+// shortens IDs to 8 characters and removes the 'sha256:' prefix
+func shortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// returns the time relative to now, e.g. "5 minutes ago"
+// accepts time.Time or unix timestamps (e.g. .Event.Time)
+func humanize(value interface{}) (string, error) {
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case int64:
+		t = time.Unix(v, 0)
+	case int:
+		t = time.Unix(int64(v), 0)
+	default:
+		return "", fmt.Errorf("humanize: unsupported type %T", value)
+	}
+
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now", nil
+	case d < time.Hour:
+		return pluralize(int(d/time.Minute), "minute") + " ago", nil
+	case d < 24*time.Hour:
+		return pluralize(int(d/time.Hour), "hour") + " ago", nil
+	default:
+		return pluralize(int(d/(24*time.Hour)), "day") + " ago", nil
+	}
+}
+
+func pluralize(count int, unit string) string {
+	if count == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
+
+// encodes a value as JSON, e.g. to embed strings in a JSON body safely
+func toJSON(value interface{}) (string, error) {
+	b, err := json.Marshal(value)
+	return string(b), err
+}
+
+// looks up a label (attribute) of the event's actor, empty if it doesn't exist
+func label(event events.Message, key string) string {
+	return event.Actor.Attributes[key]
+}
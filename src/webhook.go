@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+type webhookArgs struct {
+	Webhook              bool     `arg:"env:WEBHOOK" default:"false" help:"Enable/Disable generic webhook Notification (True/False)"`
+	WebhookURL           secret   `arg:"env:WEBHOOK_URL" help:"URL of the webhook"`
+	WebhookMethod        string   `arg:"env:WEBHOOK_METHOD" help:"HTTP method of the webhook (default: POST)"`
+	WebhookHeaders       []secret `arg:"env:WEBHOOK_HEADERS,--webhookheader" help:"Additional HTTP headers, e.g. \"Authorization: Bearer TOKEN\". The environment variable is split at commas, use WEBHOOK_HEADERS_FILE (one header per line) for values containing commas"`
+	WebhookBody          string   `arg:"env:WEBHOOK_BODY" help:"Go text/template for the request body (default: JSON with all event details)"`
+	WebhookSuccessCodes  []int    `arg:"env:WEBHOOK_SUCCESS_CODES,--webhooksuccesscode" help:"HTTP status codes treated as success (default: 200,201,202,204)"`
+	WebhookSigningSecret secret   `arg:"env:WEBHOOK_SIGNING_SECRET" help:"Secret to sign the request body with HMAC-SHA256"`
+}
+
+var webhookKind = notifierKind{
+	name: "Webhook",
+	fromArgs: func() (Notifier, bool) {
+		notifier := &webhookNotifier{
+			name:         "Webhook",
+			URL:          glb_arguments.WebhookURL,
+			Method:       glb_arguments.WebhookMethod,
+			Headers:      glb_arguments.WebhookHeaders,
+			Body:         glb_arguments.WebhookBody,
+			SuccessCodes: glb_arguments.WebhookSuccessCodes,
+		}
+		notifier.SigningSecret = glb_arguments.WebhookSigningSecret
+		return notifier, glb_arguments.Webhook
+	},
+	new: func(name string) Notifier {
+		return &webhookNotifier{name: name}
+	},
+}
+
+type webhookNotifier struct {
+	name string
+	notifierOptions
+	URL    secret `json:"url,omitempty"`
+	Method string `json:"method,omitempty"`
+	// "Name: value", the values might contain credentials
+	Headers      []secret `json:"headers,omitempty"`
+	Body         string   `json:"body,omitempty"`
+	SuccessCodes []int    `json:"success_codes,omitempty"`
+	// set up by Validate
+	header       http.Header
+	bodyTemplate *template.Template
+}
+
+// WebhookMessage is the default body of the webhook
+type WebhookMessage struct {
+	Timestamp         time.Time       `json:"timestamp"`
+	Title             string          `json:"title"`
+	Message           string          `json:"message"`
+	ServerTag         string          `json:"server_tag,omitempty"`
+	Replayed          bool            `json:"replayed"`
+	ActorID           string          `json:"actor_id,omitempty"`
+	ActorName         string          `json:"actor_name,omitempty"`
+	ActorImage        string          `json:"actor_image,omitempty"`
+	ActorImageVersion string          `json:"actor_image_version,omitempty"`
+	Event             *events.Message `json:"event"`
+}
+
+func (w *webhookNotifier) Name() string {
+	return w.name
+}
+
+func (w *webhookNotifier) Validate() error {
+	if len(w.URL) == 0 {
+		return errors.New("Webhook URL required")
+	}
+	if len(w.Method) == 0 {
+		w.Method = http.MethodPost
+	}
+	w.Method = strings.ToUpper(w.Method)
+
+	w.header = http.Header{}
+	for i, header := range w.Headers {
+		name, value, found := strings.Cut(string(header), ":")
+		if !found || len(strings.TrimSpace(name)) == 0 {
+			// the header is not shown, as it might contain credentials
+			return fmt.Errorf("invalid webhook header %d, use \"Name: value\"", i+1)
+		}
+		w.header.Add(textproto.TrimString(name), textproto.TrimString(value))
+	}
+
+	if len(w.Body) > 0 {
+		var err error
+		w.bodyTemplate, err = template.New(w.name + " body").Funcs(templateFuncs).Option("missingkey=zero").Parse(w.Body)
+		if err != nil {
+			return fmt.Errorf("invalid body template: %w", err)
+		}
+	}
+
+	if len(w.SuccessCodes) == 0 {
+		w.SuccessCodes = []int{http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent}
+	}
+	return nil
+}
+
+func (w *webhookNotifier) Describe() []setting {
+	// only show the names of the headers, their values might be credentials
+	var headers, codes []string
+	for _, header := range w.Headers {
+		name, value, _ := strings.Cut(string(header), ":")
+		headers = append(headers, name+": "+secret(strings.TrimSpace(value)).String())
+	}
+	for _, code := range w.SuccessCodes {
+		codes = append(codes, strconv.Itoa(code))
+	}
+
+	return []setting{
+		{key: "WebhookURL", value: w.URL.String()},
+		{key: "WebhookMethod", value: w.Method},
+		{key: "WebhookHeaders", value: strings.Join(headers, ", ")},
+		{key: "WebhookBody", value: w.Body},
+		{key: "WebhookSuccessCodes", value: strings.Join(codes, ",")},
+		{key: "WebhookSigningSecret", value: w.SigningSecret.String()},
+	}
+}
+
+// Send the notification to the webhook
+func (w *webhookNotifier) Send(ctx context.Context, n Notification) error {
+
+	body, err := w.render(n)
+	if err != nil {
+		return err
+	}
+
+	_, err = sendhttpRequest(ctx, w, httpRequest{
+		method:       w.Method,
+		address:      string(w.URL),
+		header:       w.header,
+		body:         body,
+		successCodes: w.SuccessCodes,
+	})
+	return err
+}
+
+// renders the body template, or the default JSON body if there is none
+func (w *webhookNotifier) render(n Notification) ([]byte, error) {
+
+	data := templateData{
+		ServerTag: glb_arguments.ServerTag,
+		Replayed:  n.Replayed,
+		Title:     n.Title,
+		Message:   n.Message,
+	}
+	if n.Event != nil {
+		data = newTemplateData(n)
+	}
+
+	if w.bodyTemplate != nil {
+		var builder strings.Builder
+		// unlike title and message, there is no sensible fallback for a custom body
+		if err := w.bodyTemplate.Execute(&builder, data); err != nil {
+			return nil, fmt.Errorf("failed to execute body template: %w", err)
+		}
+		return []byte(builder.String()), nil
+	}
+
+	return json.Marshal(WebhookMessage{
+		Timestamp:         n.Timestamp,
+		Title:             data.Title,
+		Message:           data.Message,
+		ServerTag:         data.ServerTag,
+		Replayed:          data.Replayed,
+		ActorID:           data.ActorID,
+		ActorName:         data.ActorName,
+		ActorImage:        data.ActorImage,
+		ActorImageVersion: data.ActorImageVersion,
+		Event:             n.Event,
+	})
+}